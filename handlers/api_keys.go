@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type apiKeyResponse struct {
+	Id         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newApiKeyResponse(apiKey database.ApiKey) apiKeyResponse {
+	resp := apiKeyResponse{
+		Id:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if apiKey.ExpiresAt.Valid {
+		resp.ExpiresAt = &apiKey.ExpiresAt.Time
+	}
+	if apiKey.LastUsedAt.Valid {
+		resp.LastUsedAt = &apiKey.LastUsedAt.Time
+	}
+	return resp
+}
+
+func (usersHandler *UsersHandler) HandlerCreateAPIKey(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	reqBody := struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	if reqBody.Name == "" {
+		helpers.RespondWithError(respWriter, 400, "Name cannot be empty.")
+		return
+	}
+	err = auth.ValidateScopes(reqBody.Scopes)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	expiresAt := sql.NullTime{}
+	if reqBody.ExpiresAt != nil {
+		if reqBody.ExpiresAt.Before(time.Now()) {
+			helpers.RespondWithError(respWriter, 400, "expires_at must be in the future.")
+			return
+		}
+		expiresAt = sql.NullTime{Time: *reqBody.ExpiresAt, Valid: true}
+	}
+	key, prefix, err := auth.MakeAPIKey()
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to generate api key: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	apiKey, err := usersHandler.DB.CreateAPIKey(req.Context(), database.CreateAPIKeyParams{
+		UserID:    userId,
+		Name:      reqBody.Name,
+		KeyHash:   auth.HashToken(key),
+		Prefix:    prefix,
+		Scopes:    reqBody.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to create api key: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		apiKeyResponse
+		Key string `json:"key"`
+	}{
+		apiKeyResponse: newApiKeyResponse(apiKey),
+		Key:            key,
+	}
+	helpers.RespondWithJson(respWriter, 201, resp)
+}
+
+func (usersHandler *UsersHandler) HandlerGetAPIKeys(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	apiKeys, err := usersHandler.DB.GetAPIKeysForUser(req.Context(), userId)
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to get api keys: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := make([]apiKeyResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		resp = append(resp, newApiKeyResponse(apiKey))
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (usersHandler *UsersHandler) HandlerDeleteAPIKey(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	keyId, err := uuid.Parse(req.PathValue("keyID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid keyID.")
+		return
+	}
+	_, err = usersHandler.DB.DeleteAPIKey(req.Context(), database.DeleteAPIKeyParams{ID: keyId, UserID: userId})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No api key found for the given keyID.")
+			return
+		}
+		usersHandler.Logger.Printf("Error trying to delete api key: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
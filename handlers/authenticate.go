@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/config"
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// authenticateUser resolves the user behind a request. JWT bearer tokens act
+// with the user's full permissions while ApiKey credentials and OAuth access
+// tokens are limited to the scopes they were granted. On failure the error
+// response is already written.
+func authenticateUser(apiCfg *config.ApiConfig, respWriter http.ResponseWriter, req *http.Request, scope string) (uuid.UUID, error) {
+	authHeader := req.Header.Get("Authorization")
+	if strings.HasPrefix(authHeader, "ApiKey ") {
+		return authenticateAPIKey(apiCfg, respWriter, req, scope)
+	}
+	if auth.IsAccessToken(strings.TrimPrefix(authHeader, "Bearer ")) {
+		return authenticateAccessToken(apiCfg, respWriter, req, scope)
+	}
+	return authenticateJWT(apiCfg, respWriter, req)
+}
+
+// authenticateJWT only accepts a JWT issued at login, for endpoints that manage
+// the account itself and must not be reachable with delegated credentials.
+func authenticateJWT(apiCfg *config.ApiConfig, respWriter http.ResponseWriter, req *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 401, "No Authorization header passed in request.")
+		return uuid.Nil, err
+	}
+	userId, err := auth.ValidateJWT(token, apiCfg.JWTSecret)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 401, "Invalid auth token")
+		return uuid.Nil, err
+	}
+	return userId, nil
+}
+
+func authenticateAccessToken(apiCfg *config.ApiConfig, respWriter http.ResponseWriter, req *http.Request, scope string) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 401, "No Authorization header passed in request.")
+		return uuid.Nil, err
+	}
+	accessToken, err := apiCfg.DB.GetOAuthAccessToken(req.Context(), auth.HashToken(token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 401, "Invalid access token.")
+			return uuid.Nil, err
+		}
+		apiCfg.Logger.Printf("Error trying to get oauth access token: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return uuid.Nil, err
+	}
+	if accessToken.RevokedAt.Valid || time.Now().After(accessToken.ExpiresAt) {
+		helpers.RespondWithError(respWriter, 401, "Access token expired or revoked.")
+		return uuid.Nil, fmt.Errorf("Access token expired or revoked")
+	}
+	if !auth.HasScope(accessToken.Scopes, scope) {
+		helpers.RespondWithError(respWriter, 403, fmt.Sprintf("Access token is missing the %v scope.", scope))
+		return uuid.Nil, fmt.Errorf("Access token missing scope %v", scope)
+	}
+	if isWriteRequest(req) {
+		err = rejectSuspendedUser(apiCfg, respWriter, req, accessToken.UserID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+	return accessToken.UserID, nil
+}
+
+func authenticateAPIKey(apiCfg *config.ApiConfig, respWriter http.ResponseWriter, req *http.Request, scope string) (uuid.UUID, error) {
+	key, err := auth.GetAPIKey(req.Header)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 401, "Invalid Api Key.")
+		return uuid.Nil, err
+	}
+	apiKey, err := apiCfg.DB.GetAPIKeyByHash(req.Context(), auth.HashToken(key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 401, "Invalid Api Key.")
+			return uuid.Nil, err
+		}
+		apiCfg.Logger.Printf("Error trying to get api key: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return uuid.Nil, err
+	}
+	if apiKey.ExpiresAt.Valid && time.Now().After(apiKey.ExpiresAt.Time) {
+		helpers.RespondWithError(respWriter, 401, "Api Key expired.")
+		return uuid.Nil, fmt.Errorf("Api key expired")
+	}
+	if !auth.HasScope(apiKey.Scopes, scope) {
+		helpers.RespondWithError(respWriter, 403, fmt.Sprintf("Api Key is missing the %v scope.", scope))
+		return uuid.Nil, fmt.Errorf("Api key missing scope %v", scope)
+	}
+	if isWriteRequest(req) {
+		err = rejectSuspendedUser(apiCfg, respWriter, req, apiKey.UserID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+	err = apiCfg.DB.TouchAPIKey(req.Context(), apiKey.ID)
+	if err != nil {
+		apiCfg.Logger.Printf("Error trying to update api key last_used_at: %v", err)
+	}
+	return apiKey.UserID, nil
+}
+
+// isAdminRequest reports whether the request carries an AdminKey credential.
+// authenticateAdmin checks it.
+func isAdminRequest(req *http.Request) bool {
+	return strings.HasPrefix(req.Header.Get("Authorization"), "AdminKey ")
+}
+
+// authenticateAdmin only accepts "AdminKey <ADMIN_KEY>".
+func authenticateAdmin(apiCfg *config.ApiConfig, respWriter http.ResponseWriter, req *http.Request) error {
+	adminKey, found := strings.CutPrefix(req.Header.Get("Authorization"), "AdminKey ")
+	if !found || apiCfg.AdminKey == "" || subtle.ConstantTimeCompare([]byte(adminKey), []byte(apiCfg.AdminKey)) != 1 {
+		helpers.RespondWithError(respWriter, 401, "Invalid admin key.")
+		return fmt.Errorf("Invalid admin key")
+	}
+	return nil
+}
+
+// authenticateWebhookOwner resolves who is managing webhook subscriptions. A
+// request carrying "AdminKey <ADMIN_KEY>" acts as the admin, whose subscriptions
+// have no user and receive events for every user; anything else must be a JWT.
+func authenticateWebhookOwner(apiCfg *config.ApiConfig, respWriter http.ResponseWriter, req *http.Request) (uuid.NullUUID, error) {
+	if isAdminRequest(req) {
+		return uuid.NullUUID{}, authenticateAdmin(apiCfg, respWriter, req)
+	}
+	userId, err := authenticateJWT(apiCfg, respWriter, req)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: userId, Valid: true}, nil
+}
+
+// authenticateModerator resolves who is moderating. The admin key acts as the
+// admin and is recorded without a moderator; otherwise the JWT must belong to
+// a user the admin made a moderator.
+func authenticateModerator(apiCfg *config.ApiConfig, respWriter http.ResponseWriter, req *http.Request) (uuid.NullUUID, error) {
+	if isAdminRequest(req) {
+		return uuid.NullUUID{}, authenticateAdmin(apiCfg, respWriter, req)
+	}
+	userId, err := authenticateJWT(apiCfg, respWriter, req)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	isModerator, err := apiCfg.DB.IsModerator(req.Context(), userId)
+	if err != nil {
+		apiCfg.Logger.Printf("Error checking moderator status: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return uuid.NullUUID{}, err
+	}
+	if !isModerator {
+		helpers.RespondWithError(respWriter, 403, "Only moderators can do that.")
+		return uuid.NullUUID{}, fmt.Errorf("user %v is not a moderator", userId)
+	}
+	return uuid.NullUUID{UUID: userId, Valid: true}, nil
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/database"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type relationshipResponse struct {
+	UserId    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// parseTargetUser reads the userID path value for block and mute requests and
+// makes sure it names another user that exists.
+func (usersHandler *UsersHandler) parseTargetUser(respWriter http.ResponseWriter, req *http.Request, userId uuid.UUID) (uuid.UUID, error) {
+	targetId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid userID.")
+		return uuid.Nil, err
+	}
+	if targetId == userId {
+		helpers.RespondWithError(respWriter, 400, "You cannot do that to yourself.")
+		return uuid.Nil, fmt.Errorf("user %v targeted themselves", userId)
+	}
+	_, err = usersHandler.DB.GetUser(req.Context(), targetId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No user found for the given userID.")
+			return uuid.Nil, err
+		}
+		usersHandler.Logger.Printf("Error getting user from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return uuid.Nil, err
+	}
+	return targetId, nil
+}
+
+// hasBlockBetween reports whether either user has blocked the other.
+func hasBlockBetween(ctx context.Context, db *database.Queries, userId, otherUserId uuid.UUID) (bool, error) {
+	return db.HasBlockBetween(ctx, database.HasBlockBetweenParams{BlockerID: userId, BlockedID: otherUserId})
+}
+
+// HandlerBlockUser blocks a user and removes any follows between the two, so
+// neither keeps seeing the other's chirps on their timeline.
+func (usersHandler *UsersHandler) HandlerBlockUser(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	blockedId, err := usersHandler.parseTargetUser(respWriter, req, userId)
+	if err != nil {
+		return
+	}
+	err = withTx(req.Context(), usersHandler.ApiConfig, func(qtx *database.Queries) error {
+		_, err := qtx.BlockUser(req.Context(), database.BlockUserParams{BlockerID: userId, BlockedID: blockedId})
+		if err != nil {
+			return err
+		}
+		return qtx.DeleteFollowsBetween(req.Context(), database.DeleteFollowsBetweenParams{FollowerID: userId, FolloweeID: blockedId})
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to block user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+func (usersHandler *UsersHandler) HandlerUnblockUser(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	blockedId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid userID.")
+		return
+	}
+	unblocked, err := usersHandler.DB.UnblockUser(req.Context(), database.UnblockUserParams{BlockerID: userId, BlockedID: blockedId})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to unblock user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if unblocked == 0 {
+		helpers.RespondWithError(respWriter, 404, "You have not blocked this user.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+func (usersHandler *UsersHandler) HandlerGetBlocks(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	blocks, err := usersHandler.DB.GetBlocks(req.Context(), userId)
+	if err != nil {
+		usersHandler.Logger.Printf("Error getting blocks from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := make([]relationshipResponse, 0, len(blocks))
+	for _, block := range blocks {
+		resp = append(resp, relationshipResponse{UserId: block.BlockedID, CreatedAt: block.CreatedAt})
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+// HandlerMuteUser hides a user's chirps from the muter's timelines and chirp
+// listings. Unlike a block, the muted user can't tell and can still interact.
+func (usersHandler *UsersHandler) HandlerMuteUser(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	mutedId, err := usersHandler.parseTargetUser(respWriter, req, userId)
+	if err != nil {
+		return
+	}
+	_, err = usersHandler.DB.MuteUser(req.Context(), database.MuteUserParams{MuterID: userId, MutedID: mutedId})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to mute user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+func (usersHandler *UsersHandler) HandlerUnmuteUser(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	mutedId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid userID.")
+		return
+	}
+	unmuted, err := usersHandler.DB.UnmuteUser(req.Context(), database.UnmuteUserParams{MuterID: userId, MutedID: mutedId})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to unmute user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if unmuted == 0 {
+		helpers.RespondWithError(respWriter, 404, "You have not muted this user.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+func (usersHandler *UsersHandler) HandlerGetMutes(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	mutes, err := usersHandler.DB.GetMutes(req.Context(), userId)
+	if err != nil {
+		usersHandler.Logger.Printf("Error getting mutes from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := make([]relationshipResponse, 0, len(mutes))
+	for _, mute := range mutes {
+		resp = append(resp, relationshipResponse{UserId: mute.MutedID, CreatedAt: mute.CreatedAt})
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func bearer(t *testing.T, userId uuid.UUID, secret string) string {
+	token, err := auth.MakeJWT(userId, secret, time.Hour)
+	if err != nil {
+		t.Errorf("Error making JWT: %v", err)
+		t.FailNow()
+	}
+	return "Bearer " + token
+}
+
+func TestBlockUserRemovesFollowsBothWays(t *testing.T) {
+	db := newFakeDB()
+	usersHandler := UsersHandler{db.apiConfig()}
+	userId, blocked := uuid.New(), fakeUser("blocked@example.com")
+	db.set("GetUser", fakeUsers(blocked))
+	db.set("BlockUser", fakeResult{rowsAffected: 1})
+	db.set("DeleteFollowsBetween", fakeResult{})
+
+	req := httptest.NewRequest("POST", "/api/users/"+blocked.ID.String()+"/block", nil)
+	req.SetPathValue("userID", blocked.ID.String())
+	req.Header.Set("Authorization", bearer(t, userId, usersHandler.JWTSecret))
+	respWriter := httptest.NewRecorder()
+	usersHandler.HandlerBlockUser(respWriter, req)
+	if respWriter.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %v: %v", respWriter.Code, respWriter.Body)
+		t.FailNow()
+	}
+	deletes := db.called("DeleteFollowsBetween")
+	if len(deletes) != 1 || deletes[0].args[0] != userId.String() || deletes[0].args[1] != blocked.ID.String() {
+		t.Errorf("Expected the follows between %v and %v to be deleted, got %+v.", userId, blocked.ID, deletes)
+		t.FailNow()
+	}
+	if deletes[0].tx == nil || !deletes[0].tx.committed || deletes[0].tx != db.called("BlockUser")[0].tx {
+		t.Errorf("Expected the block and the unfollow to commit together.")
+		t.FailNow()
+	}
+	for _, direction := range []string{"follower_id = $1 and followee_id = $2", "follower_id = $2 and followee_id = $1"} {
+		if !strings.Contains(deletes[0].query, direction) {
+			t.Errorf("Expected the unfollow to cover %q: %v", direction, deletes[0].query)
+			t.FailNow()
+		}
+	}
+}
+
+func TestAllowInteractionsAcrossBlock(t *testing.T) {
+	userId, other := uuid.New(), fakeUser("other@example.com")
+	cases := map[string]struct {
+		body         string
+		recipientIds []uuid.UUID
+		blocked      bool
+		allowed      bool
+	}{
+		"reply across a block":   {"hi", []uuid.UUID{other.ID}, true, false},
+		"mention across a block": {"hi @other@example.com", nil, true, false},
+		"reply without a block":  {"hi", []uuid.UUID{other.ID}, false, true},
+		"mention without block":  {"hi @other@example.com", nil, false, true},
+		"reply to yourself":      {"hi", []uuid.UUID{userId}, true, true},
+	}
+	for name, c := range cases {
+		db := newFakeDB()
+		chirpHandler := ChirpHandler{db.apiConfig()}
+		db.set("GetUsersByEmails", fakeUsers(other))
+		db.set("HasBlockBetween", fakeValue(c.blocked))
+
+		respWriter := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/chirps", nil)
+		allowed := chirpHandler.allowInteractions(respWriter, req, userId, c.body, c.recipientIds)
+		if allowed != c.allowed {
+			t.Errorf("%v: expected allowed=%v, got %v.", name, c.allowed, allowed)
+			t.FailNow()
+		}
+		if !allowed && respWriter.Code != http.StatusForbidden {
+			t.Errorf("%v: expected 403, got %v.", name, respWriter.Code)
+			t.FailNow()
+		}
+	}
+}
+
+func TestWithoutMutedAuthors(t *testing.T) {
+	db := newFakeDB()
+	chirpHandler := ChirpHandler{db.apiConfig()}
+	mutedId, otherId := uuid.New(), uuid.New()
+	db.set("GetMutedUserIDs", fakeIds(mutedId))
+	chirps := []database.Chirp{{ID: uuid.New(), UserID: mutedId}, {ID: uuid.New(), UserID: otherId}, {ID: uuid.New(), UserID: mutedId}}
+
+	req := httptest.NewRequest("GET", "/api/chirps", nil)
+	visible, err := chirpHandler.withoutMutedAuthors(httptest.NewRecorder(), req, uuid.New(), chirps)
+	if err != nil || len(visible) != 1 || visible[0].UserID != otherId {
+		t.Errorf("Expected only the chirp by %v, got %+v, %v", otherId, visible, err)
+		t.FailNow()
+	}
+}
+
+func TestOptionalViewer(t *testing.T) {
+	db := newFakeDB()
+	apiCfg := db.apiConfig()
+	userId := uuid.New()
+	cases := map[string]struct {
+		header string
+		viewer uuid.NullUUID
+	}{
+		"anonymous":        {"", uuid.NullUUID{}},
+		"signed in":        {bearer(t, userId, apiCfg.JWTSecret), uuid.NullUUID{UUID: userId, Valid: true}},
+		"malformed header": {"Bearer not-a-jwt", uuid.NullUUID{}},
+		"wrong secret":     {bearer(t, userId, "other-secret"), uuid.NullUUID{}},
+	}
+	for name, c := range cases {
+		req := httptest.NewRequest("GET", "/api/chirps", nil)
+		req.Header.Set("Authorization", c.header)
+		respWriter := httptest.NewRecorder()
+		viewer, err := optionalViewer(apiCfg, respWriter, req)
+		if err != nil || viewer != c.viewer {
+			t.Errorf("%v: expected %v, got %v, %v", name, c.viewer, viewer, err)
+			t.FailNow()
+		}
+		if respWriter.Body.Len() != 0 {
+			t.Errorf("%v: expected nothing to be written, got %v.", name, respWriter.Body)
+			t.FailNow()
+		}
+	}
+
+	db.set("GetAPIKeyByHash", fakeResult{err: errors.New("connection refused")})
+	req := httptest.NewRequest("GET", "/api/chirps", nil)
+	req.Header.Set("Authorization", "ApiKey some-key")
+	respWriter := httptest.NewRecorder()
+	if _, err := optionalViewer(apiCfg, respWriter, req); err == nil || respWriter.Code != http.StatusInternalServerError {
+		t.Errorf("Expected a database failure to still be a 500, got %v, %v.", respWriter.Code, err)
+		t.FailNow()
+	}
+}
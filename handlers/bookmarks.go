@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"Chirpy/internal/validation"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maxCollectionNameLength = 50
+
+type bookmarkResponse struct {
+	Chirp        chirpResponse `json:"chirp"`
+	CollectionId *uuid.UUID    `json:"collection_id"`
+	BookmarkedAt time.Time     `json:"bookmarked_at"`
+}
+
+type bookmarkCollectionResponse struct {
+	Id            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	BookmarkCount int64     `json:"bookmark_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// loadBookmarkedByMe sets bookmarked_by_me on chirps shown to a signed-in
+// viewer. Anonymous listings leave it out.
+func loadBookmarkedByMe(ctx context.Context, db *database.Queries, chirps []chirpResponse, viewerId uuid.NullUUID) error {
+	if !viewerId.Valid || len(chirps) == 0 {
+		return nil
+	}
+	chirpIds := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIds = append(chirpIds, chirp.ID)
+	}
+	bookmarkedIds, err := db.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{UserID: viewerId.UUID, ChirpIds: chirpIds})
+	if err != nil {
+		return err
+	}
+	bookmarked := map[uuid.UUID]bool{}
+	for _, chirpId := range bookmarkedIds {
+		bookmarked[chirpId] = true
+	}
+	for i := range chirps {
+		isBookmarked := bookmarked[chirps[i].ID]
+		chirps[i].BookmarkedByMe = &isBookmarked
+	}
+	return nil
+}
+
+func parseCollectionName(req *http.Request) (string, error) {
+	reqBody := struct {
+		Name string `json:"name"`
+	}{}
+	defer req.Body.Close()
+	err := json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		return "", fmt.Errorf("Invalid request.")
+	}
+	name := validation.Clean(reqBody.Name)
+	if name == "" {
+		return "", fmt.Errorf("Collection name cannot be empty.")
+	}
+	if validation.Length(name) > maxCollectionNameLength {
+		return "", fmt.Errorf("Collection name can be at most %v characters.", maxCollectionNameLength)
+	}
+	return name, nil
+}
+
+// HandlerBookmarkChirp saves a chirp for the caller, optionally in one of
+// their collections. Bookmarking it again moves it to the given collection.
+func (chirpHanlder *ChirpHandler) HandlerBookmarkChirp(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid chirpID.")
+		return
+	}
+	reqBody := struct {
+		CollectionId *uuid.UUID `json:"collection_id"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil && !errors.Is(err, io.EOF) {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	chirp, err := chirpHanlder.DB.GetOneChirp(req.Context(), chirpId)
+	if err != nil && err != sql.ErrNoRows {
+		chirpHanlder.Logger.Printf("Error getting chirp from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if err == sql.ErrNoRows || !isChirpVisible(chirp) {
+		helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
+		return
+	}
+	collectionId := uuid.NullUUID{}
+	if reqBody.CollectionId != nil {
+		_, err = chirpHanlder.DB.GetBookmarkCollection(req.Context(), database.GetBookmarkCollectionParams{ID: *reqBody.CollectionId, UserID: userId})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				helpers.RespondWithError(respWriter, 404, "No collection found for the given collection_id.")
+				return
+			}
+			chirpHanlder.Logger.Printf("Error getting bookmark collection from db: %v", err)
+			helpers.RespondWithError(respWriter, 500, "Internal server error.")
+			return
+		}
+		collectionId = uuid.NullUUID{UUID: *reqBody.CollectionId, Valid: true}
+	}
+	err = chirpHanlder.DB.UpsertBookmark(req.Context(), database.UpsertBookmarkParams{
+		UserID:       userId,
+		ChirpID:      chirpId,
+		CollectionID: collectionId,
+	})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to bookmark chirp: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+func (chirpHanlder *ChirpHandler) HandlerUnbookmarkChirp(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid chirpID.")
+		return
+	}
+	deleted, err := chirpHanlder.DB.DeleteBookmark(req.Context(), database.DeleteBookmarkParams{UserID: userId, ChirpID: chirpId})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to remove bookmark: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if deleted == 0 {
+		helpers.RespondWithError(respWriter, 404, "You haven't bookmarked this chirp.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerGetBookmarks pages through the caller's bookmarks, newest first,
+// optionally only those in one collection. Chirps that were hidden since are
+// left out of the page.
+func (chirpHanlder *ChirpHandler) HandlerGetBookmarks(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsRead)
+	if err != nil {
+		return
+	}
+	bookmarksPage, err := parsePage(req.URL.Query())
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	var bookmarks []database.Bookmark
+	if rawCollectionId := req.URL.Query().Get("collection_id"); rawCollectionId != "" {
+		collectionId, err := uuid.Parse(rawCollectionId)
+		if err != nil {
+			helpers.RespondWithError(respWriter, 400, "Invalid collection_id.")
+			return
+		}
+		bookmarks, err = chirpHanlder.DB.GetBookmarksInCollection(req.Context(), database.GetBookmarksInCollectionParams{
+			UserID:       userId,
+			CollectionID: uuid.NullUUID{UUID: collectionId, Valid: true},
+			BeforeAt:     bookmarksPage.BeforeAt,
+			BeforeID:     bookmarksPage.BeforeID,
+			MaxResults:   int32(bookmarksPage.Limit),
+		})
+		if err != nil {
+			chirpHanlder.Logger.Printf("Error getting bookmarks from db: %v", err)
+			helpers.RespondWithError(respWriter, 500, "Internal server error.")
+			return
+		}
+	} else {
+		bookmarks, err = chirpHanlder.DB.GetBookmarks(req.Context(), database.GetBookmarksParams{
+			UserID:     userId,
+			BeforeAt:   bookmarksPage.BeforeAt,
+			BeforeID:   bookmarksPage.BeforeID,
+			MaxResults: int32(bookmarksPage.Limit),
+		})
+		if err != nil {
+			chirpHanlder.Logger.Printf("Error getting bookmarks from db: %v", err)
+			helpers.RespondWithError(respWriter, 500, "Internal server error.")
+			return
+		}
+	}
+	chirpIds := make([]uuid.UUID, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		chirpIds = append(chirpIds, bookmark.ChirpID)
+	}
+	chirps, err := chirpHanlder.DB.GetVisibleChirpsByIDs(req.Context(), chirpIds)
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting chirps from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	chirpResps := newChirpResponses(chirps)
+	viewerId := uuid.NullUUID{UUID: userId, Valid: true}
+	err = loadChirpDetails(req.Context(), chirpHanlder.DB, chirpResps, viewerId)
+	if err == nil {
+		err = loadBookmarkedByMe(req.Context(), chirpHanlder.DB, chirpResps, viewerId)
+	}
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting chirp details from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	byId := map[uuid.UUID]chirpResponse{}
+	for _, chirp := range chirpResps {
+		byId[chirp.ID] = chirp
+	}
+	resp := struct {
+		Bookmarks  []bookmarkResponse `json:"bookmarks"`
+		NextCursor *string            `json:"next_cursor"`
+	}{Bookmarks: make([]bookmarkResponse, 0, len(bookmarks))}
+	for _, bookmark := range bookmarks {
+		chirp, ok := byId[bookmark.ChirpID]
+		if !ok {
+			continue
+		}
+		bookmarkResp := bookmarkResponse{Chirp: chirp, BookmarkedAt: bookmark.CreatedAt}
+		if bookmark.CollectionID.Valid {
+			bookmarkResp.CollectionId = &bookmark.CollectionID.UUID
+		}
+		resp.Bookmarks = append(resp.Bookmarks, bookmarkResp)
+	}
+	if len(bookmarks) > 0 {
+		last := bookmarks[len(bookmarks)-1]
+		resp.NextCursor = bookmarksPage.nextCursor(len(bookmarks), last.CreatedAt, last.ChirpID)
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (chirpHanlder *ChirpHandler) HandlerCreateBookmarkCollection(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	name, err := parseCollectionName(req)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	collection, err := chirpHanlder.DB.CreateBookmarkCollection(req.Context(), database.CreateBookmarkCollectionParams{UserID: userId, Name: name})
+	if err != nil {
+		if err == sql.ErrNoRows || isUniqueViolation(err) {
+			helpers.RespondWithError(respWriter, 409, "You already have a collection with that name.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error trying to create bookmark collection: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 201, bookmarkCollectionResponse{
+		Id:        collection.ID,
+		Name:      collection.Name,
+		CreatedAt: collection.CreatedAt,
+		UpdatedAt: collection.UpdatedAt,
+	})
+}
+
+func (chirpHanlder *ChirpHandler) HandlerGetBookmarkCollections(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsRead)
+	if err != nil {
+		return
+	}
+	collections, err := chirpHanlder.DB.GetBookmarkCollections(req.Context(), userId)
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting bookmark collections from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := make([]bookmarkCollectionResponse, 0, len(collections))
+	for _, collection := range collections {
+		resp = append(resp, bookmarkCollectionResponse{
+			Id:            collection.ID,
+			Name:          collection.Name,
+			BookmarkCount: collection.BookmarkCount,
+			CreatedAt:     collection.CreatedAt,
+			UpdatedAt:     collection.UpdatedAt,
+		})
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (chirpHanlder *ChirpHandler) HandlerRenameBookmarkCollection(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	collectionId, err := uuid.Parse(req.PathValue("collectionID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid collectionID.")
+		return
+	}
+	name, err := parseCollectionName(req)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	_, err = chirpHanlder.DB.GetBookmarkCollection(req.Context(), database.GetBookmarkCollectionParams{ID: collectionId, UserID: userId})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No collection found for the given collectionID.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error getting bookmark collection from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	collection, err := chirpHanlder.DB.RenameBookmarkCollection(req.Context(), database.RenameBookmarkCollectionParams{
+		ID:     collectionId,
+		UserID: userId,
+		Name:   name,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows || isUniqueViolation(err) {
+			helpers.RespondWithError(respWriter, 409, "You already have a collection with that name.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error trying to rename bookmark collection: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 200, bookmarkCollectionResponse{
+		Id:        collection.ID,
+		Name:      collection.Name,
+		CreatedAt: collection.CreatedAt,
+		UpdatedAt: collection.UpdatedAt,
+	})
+}
+
+// HandlerDeleteBookmarkCollection deletes a collection. Its bookmarks are
+// kept without a collection.
+func (chirpHanlder *ChirpHandler) HandlerDeleteBookmarkCollection(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	collectionId, err := uuid.Parse(req.PathValue("collectionID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid collectionID.")
+		return
+	}
+	deleted, err := chirpHanlder.DB.DeleteBookmarkCollection(req.Context(), database.DeleteBookmarkCollectionParams{ID: collectionId, UserID: userId})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to delete bookmark collection: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if deleted == 0 {
+		helpers.RespondWithError(respWriter, 404, "No collection found for the given collectionID.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestParseCollectionName(t *testing.T) {
+	cases := map[string]struct {
+		body  string
+		name  string
+		valid bool
+	}{
+		"trimmed":          {`{"name": "  Recipes  "}`, "Recipes", true},
+		"at the limit":     {`{"name": "` + strings.Repeat("é", maxCollectionNameLength) + `"}`, strings.Repeat("é", maxCollectionNameLength), true},
+		"over the limit":   {`{"name": "` + strings.Repeat("a", maxCollectionNameLength+1) + `"}`, "", false},
+		"only whitespace":  {`{"name": "   "}`, "", false},
+		"only invisible":   {`{"name": " \u200b "}`, "", false},
+		"emoji count once": {`{"name": "` + strings.Repeat("\U0001F468\u200d\U0001F469\u200d\U0001F467", maxCollectionNameLength) + `"}`, strings.Repeat("\U0001F468\u200d\U0001F469\u200d\U0001F467", maxCollectionNameLength), true},
+		"not json":         {`name`, "", false},
+	}
+	for name, c := range cases {
+		req := httptest.NewRequest("POST", "/api/bookmarks/collections", strings.NewReader(c.body))
+		parsed, err := parseCollectionName(req)
+		if (err == nil) != c.valid || parsed != c.name {
+			t.Errorf("%v: expected %q (valid=%v), got %q, %v", name, c.name, c.valid, parsed, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestBookmarkCollectionNameTaken(t *testing.T) {
+	userId, collectionId := uuid.New(), uuid.New()
+	cases := map[string]struct {
+		method string
+		set    func(db *fakeDB)
+	}{
+		"create": {"POST", func(db *fakeDB) {
+			db.empty("CreateBookmarkCollection")
+		}},
+		"rename": {"PUT", func(db *fakeDB) {
+			db.set("GetBookmarkCollection", fakeResult{
+				columns: []string{"id", "user_id", "name", "created_at", "updated_at"},
+				rows:    [][]driver.Value{{collectionId.String(), userId.String(), "Recipes", time.Now(), time.Now()}},
+			})
+			db.set("RenameBookmarkCollection", fakeResult{err: &pq.Error{Code: "23505"}})
+		}},
+	}
+	for name, c := range cases {
+		db := newFakeDB()
+		chirpHandler := ChirpHandler{db.apiConfig()}
+		c.set(db)
+		req := httptest.NewRequest(c.method, "/api/bookmarks/collections", strings.NewReader(`{"name": "recipes"}`))
+		req.SetPathValue("collectionID", collectionId.String())
+		req.Header.Set("Authorization", bearer(t, userId, chirpHandler.JWTSecret))
+		respWriter := httptest.NewRecorder()
+		if c.method == "POST" {
+			chirpHandler.HandlerCreateBookmarkCollection(respWriter, req)
+		} else {
+			chirpHandler.HandlerRenameBookmarkCollection(respWriter, req)
+		}
+		if respWriter.Code != http.StatusConflict {
+			t.Errorf("%v: expected 409, got %v: %v", name, respWriter.Code, respWriter.Body)
+			t.FailNow()
+		}
+	}
+}
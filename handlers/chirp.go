@@ -5,10 +5,20 @@ import (
 	"Chirpy/internal/auth"
 	"Chirpy/internal/config"
 	"Chirpy/internal/database"
+	"Chirpy/internal/entitlements"
+	"Chirpy/internal/notifications"
+	"Chirpy/internal/outbox"
+	"Chirpy/internal/polls"
+	"Chirpy/internal/stream"
+	"Chirpy/internal/validation"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"slices"
 	"sort"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -17,20 +27,191 @@ type ChirpHandler struct {
 	*config.ApiConfig
 }
 
-func (chirpHanlder *ChirpHandler) HandlerCreateChirp(respWriter http.ResponseWriter, req *http.Request) {
-	respWriter.Header().Set("Cache-Control", "no-cache")
-	chirp := struct {
-		Body string `json:"body"`
-	}{}
-	defer req.Body.Close()
-	token, err := auth.GetBearerToken(req.Header)
+type chirpResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	UserID    uuid.UUID  `json:"user_id"`
+	ReplyToID *uuid.UUID `json:"reply_to_id,omitempty"`
+	Published bool       `json:"published"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	Pinned    bool       `json:"pinned,omitempty"`
+
+	Attachments []attachmentResponse `json:"attachments,omitempty"`
+	LinkPreview *linkPreviewResponse `json:"link_preview,omitempty"`
+	Poll        *pollResponse        `json:"poll,omitempty"`
+
+	// BookmarkedByMe is only set for a signed-in viewer and is never part of
+	// events, which go out to everyone.
+	BookmarkedByMe *bool `json:"bookmarked_by_me,omitempty"`
+}
+
+func newChirpResponse(chirp database.Chirp) chirpResponse {
+	resp := chirpResponse{
+		ID:        chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body:      chirp.Body,
+		UserID:    chirp.UserID,
+		Published: chirp.Published,
+	}
+	if chirp.ReplyToID.Valid {
+		resp.ReplyToID = &chirp.ReplyToID.UUID
+	}
+	if chirp.PublishAt.Valid {
+		resp.PublishAt = &chirp.PublishAt.Time
+	}
+	if chirp.EditedAt.Valid {
+		resp.EditedAt = &chirp.EditedAt.Time
+	}
+	return resp
+}
+
+// isChirpVisible reports whether a chirp can be shown to other users: it has
+// been published and moderators haven't hidden it.
+func isChirpVisible(chirp database.Chirp) bool {
+	return chirp.Published && !chirp.HiddenAt.Valid
+}
+
+func newChirpResponses(chirps []database.Chirp) []chirpResponse {
+	resp := make([]chirpResponse, 0, len(chirps))
+	for _, chirp := range chirps {
+		resp = append(resp, newChirpResponse(chirp))
+	}
+	return resp
+}
+
+// loadChirpDetails fills in what is stored alongside the chirps: their
+// attachments, link previews and polls. Poll results depend on the viewer,
+// which is empty for events that anyone may receive.
+func loadChirpDetails(ctx context.Context, db *database.Queries, chirps []chirpResponse, viewerId uuid.NullUUID) error {
+	err := loadAttachments(ctx, db, chirps)
+	if err != nil {
+		return err
+	}
+	err = loadLinkPreviews(ctx, db, chirps)
 	if err != nil {
-		helpers.RespondWithError(respWriter, 401, "Must login to create chirp.")
+		return err
+	}
+	return loadPolls(ctx, db, chirps, viewerId)
+}
+
+// optionalViewer authenticates the request only if it carries credentials,
+// for endpoints that anyone can read but that show more to signed-in users.
+// Credentials that are rejected (401 or 403) are ignored and the request is
+// served as anonymous, so a stale token never breaks a public read. Other
+// failures are written as usual.
+func optionalViewer(apiCfg *config.ApiConfig, respWriter http.ResponseWriter, req *http.Request) (uuid.NullUUID, error) {
+	if req.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+	authWriter := &optionalAuthWriter{ResponseWriter: respWriter}
+	userId, err := authenticateUser(apiCfg, authWriter, req, auth.ScopeChirpsRead)
+	if authWriter.rejected {
+		return uuid.NullUUID{}, nil
+	}
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: userId, Valid: true}, nil
+}
+
+// optionalAuthWriter swallows the 401 or 403 that authenticateUser writes for
+// bad credentials and passes every other response through.
+type optionalAuthWriter struct {
+	http.ResponseWriter
+	rejected bool
+}
+
+func (authWriter *optionalAuthWriter) WriteHeader(statusCode int) {
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		authWriter.rejected = true
 		return
 	}
-	userId, err := auth.ValidateJWT(token, chirpHanlder.JWTSecret)
+	authWriter.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (authWriter *optionalAuthWriter) Write(body []byte) (int, error) {
+	if authWriter.rejected {
+		return len(body), nil
+	}
+	return authWriter.ResponseWriter.Write(body)
+}
+
+// cleanChirpBody validates a chirp body against the author's entitlements and
+// censors banned words. Every path that writes a chirp body goes through it.
+func cleanChirpBody(body string, userEntitlements entitlements.Entitlements) (string, error) {
+	body = validation.Clean(body)
+	if body == "" {
+		return "", fmt.Errorf("Chirp cannot be empty.")
+	}
+	if validation.Length(body) > userEntitlements.MaxChirpLength {
+		return "", fmt.Errorf("Chirp is too long. Max length is %v characters.", userEntitlements.MaxChirpLength)
+	}
+	wordsToBeReplaced := []string{"kerfuffle", "sharbert", "fornax"}
+	return helpers.CleanString(body, wordsToBeReplaced, "****"), nil
+}
+
+// allowChirpWrite applies the author's per-minute chirp limit and responds
+// with 429 when it is exceeded.
+func (chirpHanlder *ChirpHandler) allowChirpWrite(respWriter http.ResponseWriter, userId uuid.UUID, userEntitlements entitlements.Entitlements) bool {
+	if chirpHanlder.RateLimiter == nil || chirpHanlder.RateLimiter.Allow("chirps:"+userId.String(), userEntitlements.ChirpsPerMinute) {
+		return true
+	}
+	respWriter.Header().Set("Retry-After", "60")
+	helpers.RespondWithError(respWriter, 429, "Too many chirps. Please try again later.")
+	return false
+}
+
+// allowInteractions rejects a chirp that replies to or mentions a user when
+// either of them has blocked the other. On failure the response is written.
+func (chirpHanlder *ChirpHandler) allowInteractions(respWriter http.ResponseWriter, req *http.Request, userId uuid.UUID, body string, recipientIds []uuid.UUID) bool {
+	if emails := notifications.Mentions(body); len(emails) > 0 {
+		mentioned, err := chirpHanlder.DB.GetUsersByEmails(req.Context(), emails)
+		if err != nil {
+			chirpHanlder.Logger.Printf("Error getting mentioned users from db: %v", err)
+			helpers.RespondWithError(respWriter, 500, "500 Internal Server Error. Unable to create chirp.")
+			return false
+		}
+		for _, mentionedUser := range mentioned {
+			recipientIds = append(recipientIds, mentionedUser.ID)
+		}
+	}
+	for _, recipientId := range recipientIds {
+		if recipientId == userId {
+			continue
+		}
+		blocked, err := hasBlockBetween(req.Context(), chirpHanlder.DB, userId, recipientId)
+		if err != nil {
+			chirpHanlder.Logger.Printf("Error checking blocks: %v", err)
+			helpers.RespondWithError(respWriter, 500, "500 Internal Server Error. Unable to create chirp.")
+			return false
+		}
+		if blocked {
+			helpers.RespondWithError(respWriter, 403, "You can't reply to or mention a user you blocked or who blocked you.")
+			return false
+		}
+	}
+	return true
+}
+
+// newChirp is what a client sends to post a chirp.
+type newChirp struct {
+	Body          string       `json:"body"`
+	ReplyTo       *uuid.UUID   `json:"reply_to"`
+	PublishAt     *time.Time   `json:"publish_at"`
+	AttachmentIds []uuid.UUID  `json:"attachment_ids"`
+	Poll          *pollRequest `json:"poll"`
+}
+
+func (chirpHanlder *ChirpHandler) HandlerCreateChirp(respWriter http.ResponseWriter, req *http.Request) {
+	respWriter.Header().Set("Cache-Control", "no-cache")
+	chirp := newChirp{}
+	defer req.Body.Close()
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
 	if err != nil {
-		helpers.RespondWithError(respWriter, 401, "Invalid auth token")
 		return
 	}
 	err = json.NewDecoder(req.Body).Decode(&chirp)
@@ -39,14 +220,13 @@ func (chirpHanlder *ChirpHandler) HandlerCreateChirp(respWriter http.ResponseWri
 		helpers.RespondWithError(respWriter, 400, "Something went wrong.")
 		return
 	}
-	if len(chirp.Body) == 0 {
-		helpers.RespondWithError(respWriter, 400, "Chirp cannot be empty.")
-		return
-	}
-	if len(chirp.Body) > 140 {
-		helpers.RespondWithError(respWriter, 400, "Chirp is too long.")
-		return
-	}
+	chirpHanlder.createChirp(respWriter, req, userId, chirp, nil)
+}
+
+// createChirp validates, cleans and stores a chirp for the user and writes
+// the response. Every way of posting a chirp goes through it. inTx, if set,
+// runs in the same transaction once the chirp is stored.
+func (chirpHanlder *ChirpHandler) createChirp(respWriter http.ResponseWriter, req *http.Request, userId uuid.UUID, chirp newChirp, inTx func(qtx *database.Queries) error) {
 	user, err := chirpHanlder.DB.GetUser(req.Context(), userId)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -61,27 +241,278 @@ func (chirpHanlder *ChirpHandler) HandlerCreateChirp(respWriter http.ResponseWri
 		helpers.RespondWithError(respWriter, 400, "Invalid user_id. User doesn't exist for given user_id.")
 		return
 	}
-	wordsToBeReplaced := []string{"kerfuffle", "sharbert", "fornax"}
-	cleanedChirpBody := helpers.CleanString(chirp.Body, wordsToBeReplaced, "****")
-	newChirpParams := database.CreateChirpParams{
-		Body:   cleanedChirpBody,
-		UserID: user.ID,
+	userEntitlements := entitlements.ForUser(user)
+	cleanedChirpBody, err := cleanChirpBody(chirp.Body, userEntitlements)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	err = validateAttachmentIds(chirp.AttachmentIds)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	if chirp.PublishAt != nil {
+		if !userEntitlements.CanScheduleChirps {
+			helpers.RespondWithError(respWriter, 403, "Scheduling chirps requires Chirpy Red.")
+			return
+		}
+		if !chirp.PublishAt.After(time.Now()) {
+			helpers.RespondWithError(respWriter, 400, "publish_at must be in the future.")
+			return
+		}
+	}
+	var pollOptions []string
+	var pollClosesAt time.Time
+	if chirp.Poll != nil {
+		pollOptions, err = polls.ValidateOptions(chirp.Poll.Options)
+		if err != nil {
+			helpers.RespondWithError(respWriter, 400, err.Error())
+			return
+		}
+		// Scheduled polls open when the chirp is published.
+		opensAt := time.Now()
+		if chirp.PublishAt != nil {
+			opensAt = *chirp.PublishAt
+		}
+		pollClosesAt, err = polls.ClosingTime(opensAt, chirp.Poll.DurationMinutes)
+		if err != nil {
+			helpers.RespondWithError(respWriter, 400, err.Error())
+			return
+		}
+	}
+	replyToId := uuid.NullUUID{}
+	recipientIds := []uuid.UUID{}
+	if chirp.ReplyTo != nil {
+		parent, err := chirpHanlder.DB.GetOneChirp(req.Context(), *chirp.ReplyTo)
+		if err != nil && err != sql.ErrNoRows {
+			chirpHanlder.Logger.Printf("Error getting chirp from db: %v", err)
+			helpers.RespondWithError(respWriter, 500, "500 Internal Server Error. Unable to create chirp.")
+			return
+		}
+		if err == sql.ErrNoRows || !isChirpVisible(parent) {
+			helpers.RespondWithError(respWriter, 404, "The chirp being replied to doesn't exist.")
+			return
+		}
+		replyToId = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		recipientIds = append(recipientIds, parent.UserID)
 	}
-	insertedChirp, err := chirpHanlder.DB.CreateChirp(req.Context(), database.CreateChirpParams(newChirpParams))
+	if !chirpHanlder.allowInteractions(respWriter, req, user.ID, cleanedChirpBody, recipientIds) {
+		return
+	}
+	if !chirpHanlder.allowChirpWrite(respWriter, user.ID, userEntitlements) {
+		return
+	}
+	var insertedChirp database.Chirp
+	resp := []chirpResponse{}
+	err = withTx(req.Context(), chirpHanlder.ApiConfig, func(qtx *database.Queries) error {
+		if chirp.PublishAt != nil {
+			insertedChirp, err = qtx.CreateScheduledChirp(req.Context(), database.CreateScheduledChirpParams{
+				PublishAt: *chirp.PublishAt,
+				Body:      cleanedChirpBody,
+				UserID:    user.ID,
+				ReplyToID: replyToId,
+			})
+		} else {
+			insertedChirp, err = qtx.CreateChirp(req.Context(), database.CreateChirpParams{
+				Body:      cleanedChirpBody,
+				UserID:    user.ID,
+				ReplyToID: replyToId,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		err = attachToChirp(req.Context(), qtx, user.ID, insertedChirp.ID, chirp.AttachmentIds)
+		if err != nil {
+			return err
+		}
+		err = setChirpLink(req.Context(), qtx, insertedChirp.ID, insertedChirp.Body)
+		if err != nil {
+			return err
+		}
+		if chirp.Poll != nil {
+			err = createPoll(req.Context(), qtx, insertedChirp.ID, pollOptions, pollClosesAt)
+			if err != nil {
+				return err
+			}
+		}
+		if inTx != nil {
+			err = inTx(qtx)
+			if err != nil {
+				return err
+			}
+		}
+		resp = []chirpResponse{newChirpResponse(insertedChirp)}
+		err = loadChirpDetails(req.Context(), qtx, resp, uuid.NullUUID{UUID: user.ID, Valid: true})
+		if err != nil || !insertedChirp.Published {
+			return err
+		}
+		return outbox.Record(req.Context(), qtx, outbox.EventChirpCreated, user.ID, resp[0])
+	})
 	if err != nil {
+		if err == errUnknownAttachments {
+			helpers.RespondWithError(respWriter, 400, err.Error())
+			return
+		}
 		chirpHanlder.Logger.Printf("Error creating the chirp: %v", err)
 		helpers.RespondWithError(respWriter, 500, "500 Internal Server Error. Unable to create chirp.")
 		return
 	}
-	helpers.RespondWithJson(respWriter, 201, insertedChirp)
+	if insertedChirp.Published {
+		chirpHanlder.publishChirpEvent(stream.EventChirpCreated, resp[0])
+	}
+	helpers.RespondWithJson(respWriter, 201, resp[0])
+}
+
+func (chirpHanlder *ChirpHandler) HandlerUpdateChirp(respWriter http.ResponseWriter, req *http.Request) {
+	reqBody := struct {
+		Body string `json:"body"`
+	}{}
+	defer req.Body.Close()
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid Chirp ID.")
+		return
+	}
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request body.")
+		return
+	}
+	user, err := chirpHanlder.DB.GetUser(req.Context(), userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 401, "User not found.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error getting user from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	userEntitlements := entitlements.ForUser(user)
+	if !userEntitlements.CanEditChirps {
+		helpers.RespondWithError(respWriter, 403, "Editing chirps requires Chirpy Red.")
+		return
+	}
+	chirp, err := chirpHanlder.DB.GetOneChirp(req.Context(), chirpId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error getting chirp from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if chirp.UserID != userId {
+		helpers.RespondWithError(respWriter, 403, "You can only edit your own chirps.")
+		return
+	}
+	cleanedChirpBody, err := cleanChirpBody(reqBody.Body, userEntitlements)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	if !chirpHanlder.allowInteractions(respWriter, req, user.ID, cleanedChirpBody, nil) {
+		return
+	}
+	if !chirpHanlder.allowChirpWrite(respWriter, user.ID, userEntitlements) {
+		return
+	}
+	var updatedChirp database.Chirp
+	err = withTx(req.Context(), chirpHanlder.ApiConfig, func(qtx *database.Queries) error {
+		updatedChirp, err = qtx.UpdateChirpBody(req.Context(), database.UpdateChirpBodyParams{
+			Body: cleanedChirpBody,
+			ID:   chirpId,
+		})
+		if err != nil {
+			return err
+		}
+		return setChirpLink(req.Context(), qtx, updatedChirp.ID, updatedChirp.Body)
+	})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to update chirp: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := []chirpResponse{newChirpResponse(updatedChirp)}
+	err = loadChirpDetails(req.Context(), chirpHanlder.DB, resp, uuid.NullUUID{UUID: userId, Valid: true})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting chirp details from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 200, resp[0])
+}
+
+// PublishScheduledChirps publishes chirps whose publish_at has passed. It runs
+// as a scheduled job.
+func (chirpHanlder *ChirpHandler) PublishScheduledChirps(ctx context.Context) error {
+	var published []chirpResponse
+	err := withTx(ctx, chirpHanlder.ApiConfig, func(qtx *database.Queries) error {
+		chirps, err := qtx.PublishDueChirps(ctx)
+		if err != nil {
+			return err
+		}
+		published = newChirpResponses(chirps)
+		err = loadChirpDetails(ctx, qtx, published, uuid.NullUUID{})
+		if err != nil {
+			return err
+		}
+		for _, chirp := range published {
+			err = outbox.Record(ctx, qtx, outbox.EventChirpCreated, chirp.UserID, chirp)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(published) > 0 {
+		chirpHanlder.Logger.Printf("Published %v scheduled chirps", len(published))
+	}
+	for _, chirp := range published {
+		chirpHanlder.publishChirpEvent(stream.EventChirpCreated, chirp)
+	}
+	return nil
+}
+
+// withoutMutedAuthors drops chirps by users the caller muted. It is only used
+// for signed-in requests; asking for one author's chirps still shows them.
+func (chirpHanlder *ChirpHandler) withoutMutedAuthors(respWriter http.ResponseWriter, req *http.Request, userId uuid.UUID, chirps []database.Chirp) ([]database.Chirp, error) {
+	mutedIds, err := chirpHanlder.DB.GetMutedUserIDs(req.Context(), userId)
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting muted users from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "500 Internal Server Error. Unable to get chirps.")
+		return nil, err
+	}
+	if len(mutedIds) == 0 {
+		return chirps, nil
+	}
+	muted := map[uuid.UUID]bool{}
+	for _, mutedId := range mutedIds {
+		muted[mutedId] = true
+	}
+	return slices.DeleteFunc(chirps, func(chirp database.Chirp) bool { return muted[chirp.UserID] }), nil
 }
 
 func (chirpHanlder *ChirpHandler) HandlerGetAllCirps(respWriter http.ResponseWriter, req *http.Request) {
 	queryAuthorId := req.URL.Query().Get("author_id")
+	viewerId, err := optionalViewer(chirpHanlder.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
 	var chirps []database.Chirp
-	var err error
+	var authorId uuid.UUID
 	if queryAuthorId != "" {
-		authorId, err := uuid.Parse(queryAuthorId)
+		authorId, err = uuid.Parse(queryAuthorId)
 		if err != nil {
 			helpers.RespondWithError(respWriter, 400, "Invalid author_id")
 			return
@@ -99,6 +530,12 @@ func (chirpHanlder *ChirpHandler) HandlerGetAllCirps(respWriter http.ResponseWri
 		helpers.RespondWithError(respWriter, 500, "500 Internal Server Error. Unable to get chirps.")
 		return
 	}
+	if queryAuthorId == "" && viewerId.Valid {
+		chirps, err = chirpHanlder.withoutMutedAuthors(respWriter, req, viewerId.UUID, chirps)
+		if err != nil {
+			return
+		}
+	}
 	if len(chirps) == 0 {
 		helpers.RespondWithError(respWriter, 404, "No Chirps found.")
 		return
@@ -107,7 +544,22 @@ func (chirpHanlder *ChirpHandler) HandlerGetAllCirps(respWriter http.ResponseWri
 	if sorted == "desc" {
 		sort.Slice(chirps, func(i, j int) bool { return chirps[i].CreatedAt.After(chirps[j].CreatedAt) })
 	}
-	helpers.RespondWithJson(respWriter, 200, chirps)
+	resp := newChirpResponses(chirps)
+	if queryAuthorId != "" {
+		resp, err = pinnedFirst(req.Context(), chirpHanlder.DB, authorId, resp)
+	}
+	if err == nil {
+		err = loadChirpDetails(req.Context(), chirpHanlder.DB, resp, viewerId)
+	}
+	if err == nil {
+		err = loadBookmarkedByMe(req.Context(), chirpHanlder.DB, resp, viewerId)
+	}
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting chirp details from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "500 Internal Server Error. Unable to get chirps.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
 }
 
 func (chirpHanlder *ChirpHandler) HandlerGetOneCirps(respWriter http.ResponseWriter, req *http.Request) {
@@ -130,17 +582,64 @@ func (chirpHanlder *ChirpHandler) HandlerGetOneCirps(respWriter http.ResponseWri
 		helpers.RespondWithError(respWriter, 500, "500 Internal Server Error. Unable to get chirp.")
 		return
 	}
-	helpers.RespondWithJson(respWriter, 200, chirp)
+	if !isChirpVisible(chirp) {
+		helpers.RespondWithError(respWriter, 404, "No Chirp found for given chirpId")
+		return
+	}
+	viewerId, err := optionalViewer(chirpHanlder.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	resp := []chirpResponse{newChirpResponse(chirp)}
+	err = loadChirpDetails(req.Context(), chirpHanlder.DB, resp, viewerId)
+	if err == nil {
+		err = loadBookmarkedByMe(req.Context(), chirpHanlder.DB, resp, viewerId)
+	}
+	if err == nil {
+		resp, err = pinnedFirst(req.Context(), chirpHanlder.DB, chirp.UserID, resp)
+	}
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting chirp details from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "500 Internal Server Error. Unable to get chirp.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 200, resp[0])
 }
 
 func (chirpHanlder *ChirpHandler) HandlerDeleteCirp(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
 	id := req.PathValue("chirpID")
 	chirpId, err := uuid.Parse(id)
 	if err != nil {
 		helpers.RespondWithError(respWriter, 400, "Invalid Chirp ID.")
 		return
 	}
-	deletedChirp, err := chirpHanlder.DB.DeleteChirp(req.Context(), chirpId)
+	chirp, err := chirpHanlder.DB.GetOneChirp(req.Context(), chirpId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error getting chirp from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if chirp.UserID != userId {
+		helpers.RespondWithError(respWriter, 403, "You can only delete your own chirps.")
+		return
+	}
+	var deletedChirp database.Chirp
+	err = withTx(req.Context(), chirpHanlder.ApiConfig, func(qtx *database.Queries) error {
+		// The delete checks the author again in case the lookup raced.
+		deletedChirp, err = qtx.DeleteOwnChirp(req.Context(), database.DeleteOwnChirpParams{ID: chirpId, UserID: userId})
+		if err != nil {
+			return err
+		}
+		return outbox.Record(req.Context(), qtx, outbox.EventChirpDeleted, deletedChirp.UserID, newChirpResponse(deletedChirp))
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
@@ -154,5 +653,6 @@ func (chirpHanlder *ChirpHandler) HandlerDeleteCirp(respWriter http.ResponseWrit
 		helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
 		return
 	}
+	chirpHanlder.publishChirpEvent(stream.EventChirpDeleted, newChirpResponse(deletedChirp))
 	helpers.RespondWithJson(respWriter, 204, "")
 }
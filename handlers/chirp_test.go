@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"Chirpy/internal/database"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func deleteChirpRequest(t *testing.T, db *fakeDB, userId, chirpId uuid.UUID) *httptest.ResponseRecorder {
+	chirpHandler := ChirpHandler{db.apiConfig()}
+	req := httptest.NewRequest("DELETE", "/api/chirps/"+chirpId.String(), nil)
+	req.SetPathValue("chirpID", chirpId.String())
+	req.Header.Set("Authorization", bearer(t, userId, chirpHandler.JWTSecret))
+	respWriter := httptest.NewRecorder()
+	chirpHandler.HandlerDeleteCirp(respWriter, req)
+	return respWriter
+}
+
+func TestDeleteChirpOnlyByAuthor(t *testing.T) {
+	authorId, otherId := uuid.New(), uuid.New()
+	chirp := database.Chirp{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Body: "mine", UserID: authorId, Published: true}
+
+	db := newFakeDB()
+	db.set("GetOneChirp", fakeChirp(chirp))
+	db.set("DeleteOwnChirp", fakeChirp(chirp))
+	respWriter := deleteChirpRequest(t, db, otherId, chirp.ID)
+	if respWriter.Code != http.StatusForbidden || len(db.called("DeleteOwnChirp")) != 0 {
+		t.Errorf("Expected 403 without deleting, got %v: %v", respWriter.Code, respWriter.Body)
+		t.FailNow()
+	}
+
+	db = newFakeDB()
+	db.empty("GetOneChirp")
+	respWriter = deleteChirpRequest(t, db, otherId, chirp.ID)
+	if respWriter.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing chirp, got %v: %v", respWriter.Code, respWriter.Body)
+		t.FailNow()
+	}
+}
+
+func TestDeleteChirpRecordsEventForAuthor(t *testing.T) {
+	authorId := uuid.New()
+	chirp := database.Chirp{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Body: "mine", UserID: authorId, Published: true}
+	db := newFakeDB()
+	db.set("GetOneChirp", fakeChirp(chirp))
+	db.set("DeleteOwnChirp", fakeChirp(chirp))
+	db.set("CreateOutboxEvent", fakeOutboxEvent(authorId))
+
+	respWriter := deleteChirpRequest(t, db, authorId, chirp.ID)
+	if respWriter.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %v: %v", respWriter.Code, respWriter.Body)
+		t.FailNow()
+	}
+	deletes, events := db.called("DeleteOwnChirp"), db.called("CreateOutboxEvent")
+	if len(deletes) != 1 || deletes[0].args[1] != authorId.String() {
+		t.Errorf("Expected the delete to be limited to the author, got %+v.", deletes)
+		t.FailNow()
+	}
+	if len(events) != 1 || events[0].args[1] != authorId.String() {
+		t.Errorf("Expected the event to be recorded for the author, got %+v.", events)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"Chirpy/internal/validation"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Drafts may run past the author's chirp limit while being written; the
+	// limit applies when they are published.
+	maxDraftLength = 2000
+	maxDrafts      = 100
+)
+
+type draftResponse struct {
+	Id        uuid.UUID  `json:"id"`
+	Body      string     `json:"body"`
+	ReplyToId *uuid.UUID `json:"reply_to_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func newDraftResponse(draft database.Draft) draftResponse {
+	resp := draftResponse{
+		Id:        draft.ID,
+		Body:      draft.Body,
+		CreatedAt: draft.CreatedAt,
+		UpdatedAt: draft.UpdatedAt,
+	}
+	if draft.ReplyToID.Valid {
+		resp.ReplyToId = &draft.ReplyToID.UUID
+	}
+	return resp
+}
+
+type draftRequest struct {
+	Body    string     `json:"body"`
+	ReplyTo *uuid.UUID `json:"reply_to"`
+}
+
+func decodeDraftRequest(req *http.Request) (draftRequest, error) {
+	reqBody := draftRequest{}
+	defer req.Body.Close()
+	err := json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		return draftRequest{}, fmt.Errorf("Invalid request.")
+	}
+	// Cleaned now so the length counted here is the one publishing sees.
+	reqBody.Body = validation.Clean(reqBody.Body)
+	if validation.Length(reqBody.Body) > maxDraftLength {
+		return draftRequest{}, fmt.Errorf("Draft is too long. Max length is %v characters.", maxDraftLength)
+	}
+	return reqBody, nil
+}
+
+func (reqBody draftRequest) replyToId() uuid.NullUUID {
+	if reqBody.ReplyTo == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *reqBody.ReplyTo, Valid: true}
+}
+
+// getOwnDraft loads one of the caller's drafts from the path. Other users'
+// drafts are reported as missing. On failure the response is written.
+func (chirpHanlder *ChirpHandler) getOwnDraft(respWriter http.ResponseWriter, req *http.Request, userId uuid.UUID) (database.Draft, error) {
+	draftId, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid draftID.")
+		return database.Draft{}, err
+	}
+	draft, err := chirpHanlder.DB.GetDraft(req.Context(), database.GetDraftParams{ID: draftId, UserID: userId})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No draft found for the given draftID.")
+			return database.Draft{}, err
+		}
+		chirpHanlder.Logger.Printf("Error getting draft from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return database.Draft{}, err
+	}
+	return draft, nil
+}
+
+func (chirpHanlder *ChirpHandler) HandlerCreateDraft(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	reqBody, err := decodeDraftRequest(req)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	count, err := chirpHanlder.DB.CountDrafts(req.Context(), userId)
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error counting drafts: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if count >= maxDrafts {
+		helpers.RespondWithError(respWriter, 409, fmt.Sprintf("You can keep at most %v drafts.", maxDrafts))
+		return
+	}
+	draft, err := chirpHanlder.DB.CreateDraft(req.Context(), database.CreateDraftParams{
+		UserID:    userId,
+		Body:      reqBody.Body,
+		ReplyToID: reqBody.replyToId(),
+	})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to create draft: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 201, newDraftResponse(draft))
+}
+
+// HandlerGetDrafts pages through the caller's drafts, most recently updated
+// first.
+func (chirpHanlder *ChirpHandler) HandlerGetDrafts(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsRead)
+	if err != nil {
+		return
+	}
+	draftsPage, err := parsePage(req.URL.Query())
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	drafts, err := chirpHanlder.DB.GetDrafts(req.Context(), database.GetDraftsParams{
+		UserID:     userId,
+		BeforeAt:   draftsPage.BeforeAt,
+		BeforeID:   draftsPage.BeforeID,
+		MaxResults: int32(draftsPage.Limit),
+	})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting drafts from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		Drafts     []draftResponse `json:"drafts"`
+		NextCursor *string         `json:"next_cursor"`
+	}{Drafts: make([]draftResponse, 0, len(drafts))}
+	for _, draft := range drafts {
+		resp.Drafts = append(resp.Drafts, newDraftResponse(draft))
+	}
+	if len(drafts) > 0 {
+		last := drafts[len(drafts)-1]
+		resp.NextCursor = draftsPage.nextCursor(len(drafts), last.UpdatedAt, last.ID)
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (chirpHanlder *ChirpHandler) HandlerGetDraft(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsRead)
+	if err != nil {
+		return
+	}
+	draft, err := chirpHanlder.getOwnDraft(respWriter, req, userId)
+	if err != nil {
+		return
+	}
+	helpers.RespondWithJson(respWriter, 200, newDraftResponse(draft))
+}
+
+// HandlerUpdateDraft replaces the draft's contents. The last save wins.
+func (chirpHanlder *ChirpHandler) HandlerUpdateDraft(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	draftId, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid draftID.")
+		return
+	}
+	reqBody, err := decodeDraftRequest(req)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	draft, err := chirpHanlder.DB.UpdateDraft(req.Context(), database.UpdateDraftParams{
+		ID:        draftId,
+		UserID:    userId,
+		Body:      reqBody.Body,
+		ReplyToID: reqBody.replyToId(),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No draft found for the given draftID.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error trying to update draft: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 200, newDraftResponse(draft))
+}
+
+func (chirpHanlder *ChirpHandler) HandlerDeleteDraft(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	draftId, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid draftID.")
+		return
+	}
+	deleted, err := chirpHanlder.DB.DeleteDraft(req.Context(), database.DeleteDraftParams{ID: draftId, UserID: userId})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to delete draft: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if deleted == 0 {
+		helpers.RespondWithError(respWriter, 404, "No draft found for the given draftID.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerPublishDraft posts the draft as a chirp exactly as if it had been
+// sent to HandlerCreateChirp, and deletes the draft in the same transaction.
+// The optional body adds what drafts don't keep: publish_at, attachment_ids
+// and a poll.
+func (chirpHanlder *ChirpHandler) HandlerPublishDraft(respWriter http.ResponseWriter, req *http.Request) {
+	respWriter.Header().Set("Cache-Control", "no-cache")
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	draft, err := chirpHanlder.getOwnDraft(respWriter, req, userId)
+	if err != nil {
+		return
+	}
+	chirp := newChirp{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&chirp)
+	if err != nil && !errors.Is(err, io.EOF) {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	chirp.Body = draft.Body
+	chirp.ReplyTo = nil
+	if draft.ReplyToID.Valid {
+		chirp.ReplyTo = &draft.ReplyToID.UUID
+	}
+	chirpHanlder.createChirp(respWriter, req, userId, chirp, func(qtx *database.Queries) error {
+		// Another device may have deleted it meanwhile, which is fine.
+		_, err := qtx.DeleteDraft(req.Context(), database.DeleteDraftParams{ID: draft.ID, UserID: userId})
+		return err
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"Chirpy/internal/database"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func fakeDraft(draft database.Draft) fakeResult {
+	return fakeResult{
+		columns: []string{"id", "user_id", "body", "reply_to_id", "created_at", "updated_at"},
+		rows:    [][]driver.Value{{draft.ID.String(), draft.UserID.String(), draft.Body, nil, draft.CreatedAt, draft.UpdatedAt}},
+	}
+}
+
+func fakeChirp(chirp database.Chirp) fakeResult {
+	return fakeResult{
+		columns: []string{"id", "created_at", "updated_at", "body", "user_id", "published", "publish_at", "edited_at", "reply_to_id", "hidden_at", "link_url"},
+		rows:    [][]driver.Value{{chirp.ID.String(), chirp.CreatedAt, chirp.UpdatedAt, chirp.Body, chirp.UserID.String(), chirp.Published, nil, nil, nil, nil, nil}},
+	}
+}
+
+func TestDecodeDraftRequest(t *testing.T) {
+	cases := map[string]struct {
+		body    string
+		valid   bool
+		cleaned string
+	}{
+		"empty draft":         {`{"body": ""}`, true, ""},
+		"cleaned":             {`{"body": "  hi\u200b  "}`, true, "hi"},
+		"at the limit":        {`{"body": "` + strings.Repeat("é", maxDraftLength) + `"}`, true, strings.Repeat("é", maxDraftLength)},
+		"over the limit":      {`{"body": "` + strings.Repeat("a", maxDraftLength+1) + `"}`, false, ""},
+		"not json":            {`body`, false, ""},
+		"reply to an invalid": {`{"body": "hi", "reply_to": "nope"}`, false, ""},
+	}
+	for name, c := range cases {
+		req := httptest.NewRequest("POST", "/api/drafts", strings.NewReader(c.body))
+		reqBody, err := decodeDraftRequest(req)
+		if (err == nil) != c.valid {
+			t.Errorf("%v: expected valid=%v, got %v.", name, c.valid, err)
+			t.FailNow()
+		}
+		if c.valid && reqBody.Body != c.cleaned {
+			t.Errorf("%v: expected body %q, got %q.", name, c.cleaned, reqBody.Body)
+			t.FailNow()
+		}
+	}
+}
+
+func publishDraftRequest(t *testing.T, secret string, userId, draftId uuid.UUID) *http.Request {
+	req := httptest.NewRequest("POST", "/api/drafts/"+draftId.String()+"/publish", nil)
+	req.SetPathValue("draftID", draftId.String())
+	req.Header.Set("Authorization", bearer(t, userId, secret))
+	return req
+}
+
+func TestPublishDraft(t *testing.T) {
+	db := newFakeDB()
+	chirpHandler := ChirpHandler{db.apiConfig()}
+	user := fakeUser("author@example.com")
+	draft := database.Draft{ID: uuid.New(), UserID: user.ID, Body: "hello from a draft", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	chirp := database.Chirp{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Body: draft.Body, UserID: user.ID, Published: true}
+	db.set("GetDraft", fakeDraft(draft))
+	db.set("GetUser", fakeUsers(user))
+	db.set("CreateChirp", fakeChirp(chirp))
+	db.set("DeleteDraft", fakeResult{rowsAffected: 1})
+	db.set("SetChirpLink", fakeResult{})
+	db.empty("GetAttachmentsForChirps", "GetLinkPreviewsForChirps", "GetPollOptionsForChirps")
+	db.set("CreateOutboxEvent", fakeOutboxEvent(user.ID))
+
+	respWriter := httptest.NewRecorder()
+	chirpHandler.HandlerPublishDraft(respWriter, publishDraftRequest(t, chirpHandler.JWTSecret, user.ID, draft.ID))
+	if respWriter.Code != http.StatusCreated {
+		t.Errorf("Expected 201, got %v: %v", respWriter.Code, respWriter.Body)
+		t.FailNow()
+	}
+	creates, deletes := db.called("CreateChirp"), db.called("DeleteDraft")
+	if len(creates) != 1 || creates[0].args[0] != draft.Body {
+		t.Errorf("Expected the draft body to be posted, got %+v.", creates)
+		t.FailNow()
+	}
+	if len(deletes) != 1 || deletes[0].tx == nil || deletes[0].tx != creates[0].tx || !deletes[0].tx.committed {
+		t.Errorf("Expected the draft to be deleted in the transaction that posted it.")
+		t.FailNow()
+	}
+}
+
+func TestPublishDraftOverChirpLimit(t *testing.T) {
+	db := newFakeDB()
+	chirpHandler := ChirpHandler{db.apiConfig()}
+	user := fakeUser("author@example.com")
+	draft := database.Draft{ID: uuid.New(), UserID: user.ID, Body: strings.Repeat("a", maxDraftLength), CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	db.set("GetDraft", fakeDraft(draft))
+	db.set("GetUser", fakeUsers(user))
+
+	respWriter := httptest.NewRecorder()
+	chirpHandler.HandlerPublishDraft(respWriter, publishDraftRequest(t, chirpHandler.JWTSecret, user.ID, draft.ID))
+	if respWriter.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %v: %v", respWriter.Code, respWriter.Body)
+		t.FailNow()
+	}
+	if len(db.called("CreateChirp")) != 0 || len(db.called("DeleteDraft")) != 0 {
+		t.Errorf("Expected the draft to be kept when it can't be published.")
+		t.FailNow()
+	}
+}
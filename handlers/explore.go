@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/database"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// HandlerGetExploreChirps pages through the explore ranking, best first.
+// Rankings are recomputed every few minutes, so paging across a refresh may
+// repeat or skip a chirp. Chirps hidden since the last refresh and, for a
+// signed-in viewer, chirps by muted users are left out of the page.
+func (chirpHanlder *ChirpHandler) HandlerGetExploreChirps(respWriter http.ResponseWriter, req *http.Request) {
+	viewerId, err := optionalViewer(chirpHanlder.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	explorePage, err := parseRankPage(req.URL.Query())
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	rankings, err := chirpHanlder.DB.GetChirpRankings(req.Context(), database.GetChirpRankingsParams{
+		AfterRank:  explorePage.AfterRank,
+		MaxResults: int32(explorePage.Limit),
+	})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting chirp rankings from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	chirpIds := make([]uuid.UUID, 0, len(rankings))
+	for _, ranking := range rankings {
+		chirpIds = append(chirpIds, ranking.ChirpID)
+	}
+	chirps, err := chirpHanlder.DB.GetVisibleChirpsByIDs(req.Context(), chirpIds)
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting chirps from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if viewerId.Valid {
+		chirps, err = chirpHanlder.withoutMutedAuthors(respWriter, req, viewerId.UUID, chirps)
+		if err != nil {
+			return
+		}
+	}
+	chirpResps := newChirpResponses(chirps)
+	err = loadChirpDetails(req.Context(), chirpHanlder.DB, chirpResps, viewerId)
+	if err == nil {
+		err = loadBookmarkedByMe(req.Context(), chirpHanlder.DB, chirpResps, viewerId)
+	}
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting chirp details from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	byId := map[uuid.UUID]chirpResponse{}
+	for _, chirp := range chirpResps {
+		byId[chirp.ID] = chirp
+	}
+	resp := struct {
+		Chirps     []chirpResponse `json:"chirps"`
+		NextCursor *string         `json:"next_cursor"`
+	}{Chirps: make([]chirpResponse, 0, len(rankings))}
+	for _, ranking := range rankings {
+		if chirp, ok := byId[ranking.ChirpID]; ok {
+			resp.Chirps = append(resp.Chirps, chirp)
+		}
+	}
+	if len(rankings) > 0 {
+		resp.NextCursor = explorePage.nextCursor(len(rankings), rankings[len(rankings)-1].Rank)
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
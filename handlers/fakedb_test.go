@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"Chirpy/internal/config"
+	"Chirpy/internal/database"
+	"Chirpy/internal/stream"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeDB answers the generated queries by name so handlers can be tested
+// without Postgres. Queries without a result fail the request.
+type fakeDB struct {
+	mu      sync.Mutex
+	results map[string]fakeResult
+	calls   []fakeCall
+}
+
+type fakeResult struct {
+	columns      []string
+	rows         [][]driver.Value
+	rowsAffected int64
+	err          error
+}
+
+type fakeCall struct {
+	name  string
+	query string
+	args  []driver.Value
+	tx    *fakeTx
+}
+
+type fakeTx struct {
+	committed bool
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{results: map[string]fakeResult{}}
+}
+
+// apiConfig returns a config whose DB and SQLDB both go through the fake.
+func (db *fakeDB) apiConfig() *config.ApiConfig {
+	sqlDB := sql.OpenDB(fakeConnector{db: db})
+	return &config.ApiConfig{
+		Logger:    log.New(io.Discard, "", 0),
+		DB:        database.New(sqlDB),
+		SQLDB:     sqlDB,
+		JWTSecret: "test-secret",
+		Stream:    stream.NewHub(10),
+	}
+}
+
+func (db *fakeDB) set(name string, result fakeResult) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.results[name] = result
+}
+
+// empty makes the named queries return no rows.
+func (db *fakeDB) empty(names ...string) {
+	for _, name := range names {
+		db.set(name, fakeResult{})
+	}
+}
+
+// called returns the calls made to the named query.
+func (db *fakeDB) called(name string) []fakeCall {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	calls := []fakeCall{}
+	for _, call := range db.calls {
+		if call.name == name {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (db *fakeDB) run(query string, args []driver.NamedValue, tx *fakeTx) (fakeResult, error) {
+	name := strings.Fields(strings.TrimPrefix(query, "-- name: "))[0]
+	values := make([]driver.Value, len(args))
+	for idx, arg := range args {
+		values[idx] = arg.Value
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.calls = append(db.calls, fakeCall{name: name, query: query, args: values, tx: tx})
+	result, ok := db.results[name]
+	if !ok {
+		return result, fmt.Errorf("fakeDB: no result for %v", name)
+	}
+	return result, result.err
+}
+
+type fakeConnector struct {
+	db *fakeDB
+}
+
+func (connector fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: connector.db}, nil
+}
+
+func (connector fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, fmt.Errorf("fakeDB: use a connector")
+}
+
+type fakeConn struct {
+	db *fakeDB
+	tx *fakeTx
+}
+
+func (conn *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakeDB: prepared statements are not supported")
+}
+
+func (conn *fakeConn) Close() error {
+	return nil
+}
+
+func (conn *fakeConn) Begin() (driver.Tx, error) {
+	conn.tx = &fakeTx{}
+	return conn, nil
+}
+
+func (conn *fakeConn) Commit() error {
+	conn.db.mu.Lock()
+	conn.tx.committed = true
+	conn.db.mu.Unlock()
+	conn.tx = nil
+	return nil
+}
+
+func (conn *fakeConn) Rollback() error {
+	conn.tx = nil
+	return nil
+}
+
+func (conn *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := conn.db.run(query, args, conn.tx)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(result.rowsAffected), nil
+}
+
+func (conn *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result, err := conn.db.run(query, args, conn.tx)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: result.columns, rows: result.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (rows *fakeRows) Columns() []string {
+	return rows.columns
+}
+
+func (rows *fakeRows) Close() error {
+	return nil
+}
+
+func (rows *fakeRows) Next(dest []driver.Value) error {
+	if len(rows.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, rows.rows[0])
+	rows.rows = rows.rows[1:]
+	return nil
+}
+
+// Helpers for the rows the tests need most.
+
+func fakeValue(value any) fakeResult {
+	return fakeResult{columns: []string{"value"}, rows: [][]driver.Value{{value}}}
+}
+
+func fakeIds(ids ...uuid.UUID) fakeResult {
+	result := fakeResult{columns: []string{"id"}}
+	for _, id := range ids {
+		result.rows = append(result.rows, []driver.Value{id.String()})
+	}
+	return result
+}
+
+func fakeUsers(users ...database.User) fakeResult {
+	result := fakeResult{columns: []string{"id", "created_at", "updated_at", "email", "hashed_password", "is_chirpy_red", "suspended_at", "suspended_until", "suspension_reason"}}
+	for _, user := range users {
+		result.rows = append(result.rows, []driver.Value{user.ID.String(), user.CreatedAt, user.UpdatedAt, user.Email, user.HashedPassword, user.IsChirpyRed, nil, nil, nil})
+	}
+	return result
+}
+
+func fakeUser(email string) database.User {
+	return database.User{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Email: email}
+}
+
+func fakeOutboxEvent(userId uuid.UUID) fakeResult {
+	return fakeResult{
+		columns: []string{"id", "event", "user_id", "payload", "attempts", "last_error", "next_attempt_at", "published_at", "created_at"},
+		rows:    [][]driver.Value{{uuid.New().String(), "chirp.created", userId.String(), []byte("{}"), int64(0), nil, time.Now(), nil, time.Now()}},
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/database"
+	"Chirpy/internal/outbox"
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (usersHandler *UsersHandler) HandlerFollowUser(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	followeeId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid userID.")
+		return
+	}
+	if followeeId == userId {
+		helpers.RespondWithError(respWriter, 400, "You cannot follow yourself.")
+		return
+	}
+	_, err = usersHandler.DB.GetUser(req.Context(), followeeId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No user found for the given userID.")
+			return
+		}
+		usersHandler.Logger.Printf("Error getting user from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	blocked, err := hasBlockBetween(req.Context(), usersHandler.DB, userId, followeeId)
+	if err != nil {
+		usersHandler.Logger.Printf("Error checking blocks: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if blocked {
+		helpers.RespondWithError(respWriter, 403, "You can't follow this user.")
+		return
+	}
+	err = withTx(req.Context(), usersHandler.ApiConfig, func(qtx *database.Queries) error {
+		followed, err := qtx.FollowUser(req.Context(), database.FollowUserParams{FollowerID: userId, FolloweeID: followeeId})
+		if err != nil || followed == 0 {
+			return err
+		}
+		return outbox.Record(req.Context(), qtx, outbox.EventUserFollowed, userId, struct {
+			FollowerId uuid.UUID `json:"follower_id"`
+			FolloweeId uuid.UUID `json:"followee_id"`
+		}{FollowerId: userId, FolloweeId: followeeId})
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to follow user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+func (usersHandler *UsersHandler) HandlerUnfollowUser(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	followeeId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid userID.")
+		return
+	}
+	unfollowed, err := usersHandler.DB.UnfollowUser(req.Context(), database.UnfollowUserParams{FollowerID: userId, FolloweeID: followeeId})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to unfollow user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if unfollowed == 0 {
+		helpers.RespondWithError(respWriter, 404, "You are not following this user.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"Chirpy/internal/outbox"
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (chirpHanlder *ChirpHandler) HandlerLikeChirp(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid chirpID.")
+		return
+	}
+	chirp, err := chirpHanlder.DB.GetOneChirp(req.Context(), chirpId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error getting chirp from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if !isChirpVisible(chirp) {
+		helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
+		return
+	}
+	err = withTx(req.Context(), chirpHanlder.ApiConfig, func(qtx *database.Queries) error {
+		liked, err := qtx.LikeChirp(req.Context(), database.LikeChirpParams{UserID: userId, ChirpID: chirpId})
+		if err != nil || liked == 0 {
+			return err
+		}
+		return outbox.Record(req.Context(), qtx, outbox.EventChirpLiked, userId, struct {
+			UserId   uuid.UUID `json:"user_id"`
+			ChirpId  uuid.UUID `json:"chirp_id"`
+			AuthorId uuid.UUID `json:"author_id"`
+		}{UserId: userId, ChirpId: chirpId, AuthorId: chirp.UserID})
+	})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to like chirp: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+func (chirpHanlder *ChirpHandler) HandlerUnlikeChirp(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid chirpID.")
+		return
+	}
+	unliked, err := chirpHanlder.DB.UnlikeChirp(req.Context(), database.UnlikeChirpParams{UserID: userId, ChirpID: chirpId})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to unlike chirp: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if unliked == 0 {
+		helpers.RespondWithError(respWriter, 404, "You haven't liked this chirp.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"Chirpy/internal/database"
+	"Chirpy/internal/linkpreview"
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type linkPreviewResponse struct {
+	Url         string  `json:"url"`
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	ImageUrl    *string `json:"image_url"`
+	SiteName    *string `json:"site_name"`
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+// setChirpLink records the chirp's first URL and queues it for unfurling. The
+// preview shows up in responses once the unfurl job has fetched it, right
+// away if another chirp already linked the page.
+func setChirpLink(ctx context.Context, qtx *database.Queries, chirpId uuid.UUID, body string) error {
+	link := linkpreview.FirstURL(body)
+	err := qtx.SetChirpLink(ctx, database.SetChirpLinkParams{
+		ID:      chirpId,
+		LinkUrl: sql.NullString{String: link, Valid: link != ""},
+	})
+	if err != nil || link == "" {
+		return err
+	}
+	return qtx.QueueLinkPreview(ctx, link)
+}
+
+func loadLinkPreviews(ctx context.Context, db *database.Queries, chirps []chirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+	chirpIds := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIds = append(chirpIds, chirp.ID)
+	}
+	previews, err := db.GetLinkPreviewsForChirps(ctx, chirpIds)
+	if err != nil {
+		return err
+	}
+	byChirp := map[uuid.UUID]*linkPreviewResponse{}
+	for _, preview := range previews {
+		byChirp[preview.ChirpID] = &linkPreviewResponse{
+			Url:         preview.Url,
+			Title:       nullStringPtr(preview.Title),
+			Description: nullStringPtr(preview.Description),
+			ImageUrl:    nullStringPtr(preview.ImageUrl),
+			SiteName:    nullStringPtr(preview.SiteName),
+		}
+	}
+	for i := range chirps {
+		chirps[i].LinkPreview = byChirp[chirps[i].ID]
+	}
+	return nil
+}
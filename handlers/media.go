@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"Chirpy/internal/media"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// detachedAttachmentTTL is how long an upload may wait to be attached to a
+// chirp before it is deleted.
+const detachedAttachmentTTL = 24 * time.Hour
+
+var errUnknownAttachments = errors.New("Attachments not found or already used.")
+
+type attachmentResponse struct {
+	Id           uuid.UUID `json:"id"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Url          string    `json:"url"`
+	ThumbnailUrl string    `json:"thumbnail_url"`
+}
+
+func newAttachmentResponse(attachment database.Attachment) attachmentResponse {
+	return attachmentResponse{
+		Id:           attachment.ID,
+		ContentType:  attachment.ContentType,
+		Width:        attachment.Width,
+		Height:       attachment.Height,
+		SizeBytes:    attachment.SizeBytes,
+		Url:          fmt.Sprintf("/api/media/%v", attachment.ID),
+		ThumbnailUrl: fmt.Sprintf("/api/media/%v/thumbnail", attachment.ID),
+	}
+}
+
+// validateAttachmentIds checks the attachment list sent with a new chirp.
+func validateAttachmentIds(attachmentIds []uuid.UUID) error {
+	if len(attachmentIds) > media.MaxAttachments {
+		return fmt.Errorf("A chirp can have at most %v attachments.", media.MaxAttachments)
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, attachmentId := range attachmentIds {
+		if seen[attachmentId] {
+			return fmt.Errorf("Attachments must not repeat.")
+		}
+		seen[attachmentId] = true
+	}
+	return nil
+}
+
+// attachToChirp claims the user's unused uploads for the chirp in the order
+// given. It fails with errUnknownAttachments if any of them can't be claimed.
+func attachToChirp(ctx context.Context, qtx *database.Queries, userId uuid.UUID, chirpId uuid.UUID, attachmentIds []uuid.UUID) error {
+	if len(attachmentIds) == 0 {
+		return nil
+	}
+	attached, err := qtx.AttachToChirp(ctx, database.AttachToChirpParams{
+		ChirpID: uuid.NullUUID{UUID: chirpId, Valid: true},
+		Ids:     attachmentIds,
+		UserID:  userId,
+	})
+	if err != nil {
+		return err
+	}
+	if attached != int64(len(attachmentIds)) {
+		return errUnknownAttachments
+	}
+	return nil
+}
+
+// loadAttachments fills in the attachments of the given chirps with one query.
+func loadAttachments(ctx context.Context, db *database.Queries, chirps []chirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+	chirpIds := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIds = append(chirpIds, chirp.ID)
+	}
+	attachments, err := db.GetAttachmentsForChirps(ctx, chirpIds)
+	if err != nil {
+		return err
+	}
+	byChirp := map[uuid.UUID][]attachmentResponse{}
+	for _, attachment := range attachments {
+		byChirp[attachment.ChirpID.UUID] = append(byChirp[attachment.ChirpID.UUID], newAttachmentResponse(attachment))
+	}
+	for i := range chirps {
+		chirps[i].Attachments = byChirp[chirps[i].ID]
+	}
+	return nil
+}
+
+// HandlerUploadMedia accepts a single image in the "file" field of a
+// multipart form. The returned id is then passed in attachment_ids when
+// creating a chirp.
+func (chirpHanlder *ChirpHandler) HandlerUploadMedia(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	// Leave room for the multipart headers around the file.
+	req.Body = http.MaxBytesReader(respWriter, req.Body, media.MaxUploadBytes+64<<10)
+	defer req.Body.Close()
+	file, _, err := req.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			helpers.RespondWithError(respWriter, 413, fmt.Sprintf("Image is too large. Max size is %v MB.", media.MaxUploadBytes>>20))
+			return
+		}
+		helpers.RespondWithError(respWriter, 400, "Expected a multipart form with the image in the file field.")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadBytes+1))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Unable to read the uploaded file.")
+		return
+	}
+	if len(data) > media.MaxUploadBytes {
+		helpers.RespondWithError(respWriter, 413, fmt.Sprintf("Image is too large. Max size is %v MB.", media.MaxUploadBytes>>20))
+		return
+	}
+	processed, err := media.Process(data)
+	if err != nil {
+		if err == media.ErrUnsupportedType {
+			helpers.RespondWithError(respWriter, 415, err.Error())
+			return
+		}
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	attachmentId := uuid.New()
+	blobKey := "attachments/" + attachmentId.String() + media.Extension(processed.ContentType)
+	thumbnailKey := "attachments/" + attachmentId.String() + "_thumb" + media.Extension(processed.ThumbnailContentType)
+	err = chirpHanlder.Media.Put(req.Context(), blobKey, bytes.NewReader(processed.Data))
+	if err == nil {
+		err = chirpHanlder.Media.Put(req.Context(), thumbnailKey, bytes.NewReader(processed.Thumbnail))
+	}
+	var attachment database.Attachment
+	if err == nil {
+		attachment, err = chirpHanlder.DB.CreateAttachment(req.Context(), database.CreateAttachmentParams{
+			ID:           attachmentId,
+			UserID:       userId,
+			ContentType:  processed.ContentType,
+			SizeBytes:    int64(len(processed.Data)),
+			Width:        int32(processed.Width),
+			Height:       int32(processed.Height),
+			BlobKey:      blobKey,
+			ThumbnailKey: thumbnailKey,
+		})
+	}
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to store attachment: %v", err)
+		chirpHanlder.deleteBlobs(context.Background(), blobKey, thumbnailKey)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 201, newAttachmentResponse(attachment))
+}
+
+func (chirpHanlder *ChirpHandler) HandlerGetMedia(respWriter http.ResponseWriter, req *http.Request) {
+	chirpHanlder.serveAttachment(respWriter, req, false)
+}
+
+func (chirpHanlder *ChirpHandler) HandlerGetMediaThumbnail(respWriter http.ResponseWriter, req *http.Request) {
+	chirpHanlder.serveAttachment(respWriter, req, true)
+}
+
+// serveAttachment streams an upload from the blob store. Uploads not yet
+// attached are only served to their uploader, so the author can preview them;
+// once attached they follow the chirp and disappear when it is hidden,
+// unpublished or deleted.
+func (chirpHanlder *ChirpHandler) serveAttachment(respWriter http.ResponseWriter, req *http.Request, thumbnail bool) {
+	attachmentId, err := uuid.Parse(req.PathValue("attachmentID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid attachmentID.")
+		return
+	}
+	attachment, err := chirpHanlder.DB.GetAttachment(req.Context(), attachmentId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No attachment found for the given attachmentID.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error getting attachment from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	cacheControl := "public, max-age=3600"
+	if !attachment.AttachedAt.Valid {
+		userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsRead)
+		if err != nil {
+			return
+		}
+		if attachment.UserID != userId {
+			helpers.RespondWithError(respWriter, 404, "No attachment found for the given attachmentID.")
+			return
+		}
+		cacheControl = "private, no-store"
+	} else {
+		visible := false
+		if attachment.ChirpID.Valid {
+			chirp, err := chirpHanlder.DB.GetOneChirp(req.Context(), attachment.ChirpID.UUID)
+			if err != nil && err != sql.ErrNoRows {
+				chirpHanlder.Logger.Printf("Error getting chirp from db: %v", err)
+				helpers.RespondWithError(respWriter, 500, "Internal server error.")
+				return
+			}
+			visible = err == nil && isChirpVisible(chirp)
+		}
+		if !visible {
+			helpers.RespondWithError(respWriter, 404, "No attachment found for the given attachmentID.")
+			return
+		}
+	}
+	key, contentType := attachment.BlobKey, attachment.ContentType
+	if thumbnail {
+		key, contentType = attachment.ThumbnailKey, media.ThumbnailContentType(attachment.ContentType)
+	}
+	blob, err := chirpHanlder.Media.Open(req.Context(), key)
+	if err != nil {
+		if err == media.ErrBlobNotFound {
+			helpers.RespondWithError(respWriter, 404, "No attachment found for the given attachmentID.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error opening attachment blob: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	defer blob.Close()
+	respWriter.Header().Set("Content-Type", contentType)
+	respWriter.Header().Set("X-Content-Type-Options", "nosniff")
+	respWriter.Header().Set("Cache-Control", cacheControl)
+	respWriter.WriteHeader(200)
+	io.Copy(respWriter, blob)
+}
+
+func (chirpHanlder *ChirpHandler) deleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		err := chirpHanlder.Media.Delete(ctx, key)
+		if err != nil {
+			chirpHanlder.Logger.Printf("Error deleting blob %v: %v", key, err)
+		}
+	}
+}
+
+// PruneAttachments deletes uploads that were never attached to a chirp, or
+// whose chirp was deleted, along with their blobs. It runs as a scheduled job.
+func (chirpHanlder *ChirpHandler) PruneAttachments(ctx context.Context) error {
+	deleted, err := chirpHanlder.DB.DeleteDetachedAttachments(ctx, time.Now().Add(-detachedAttachmentTTL))
+	if err != nil {
+		return err
+	}
+	for _, attachment := range deleted {
+		chirpHanlder.deleteBlobs(ctx, attachment.BlobKey, attachment.ThumbnailKey)
+	}
+	if len(deleted) > 0 {
+		chirpHanlder.Logger.Printf("Deleted %v detached attachments", len(deleted))
+	}
+	return nil
+}
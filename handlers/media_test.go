@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"Chirpy/internal/media"
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func fakeUnattachedUpload(id, userId uuid.UUID) fakeResult {
+	return fakeResult{
+		columns: []string{"id", "user_id", "chirp_id", "position", "content_type", "size_bytes", "width", "height", "blob_key", "thumbnail_key", "created_at", "attached_at"},
+		rows:    [][]driver.Value{{id.String(), userId.String(), nil, int64(0), "image/png", int64(4), int64(1), int64(1), "image", "thumbnail", time.Now(), nil}},
+	}
+}
+
+func TestUnattachedMediaOnlyForUploader(t *testing.T) {
+	uploaderId, attachmentId := uuid.New(), uuid.New()
+	store, err := media.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Errorf("Error creating blob store: %v", err)
+		t.FailNow()
+	}
+	if err := store.Put(context.Background(), "image", strings.NewReader("png!")); err != nil {
+		t.Errorf("Error storing blob: %v", err)
+		t.FailNow()
+	}
+	db := newFakeDB()
+	db.set("GetAttachment", fakeUnattachedUpload(attachmentId, uploaderId))
+	chirpHandler := ChirpHandler{db.apiConfig()}
+	chirpHandler.Media = store
+
+	cases := map[string]struct {
+		authorization string
+		code          int
+	}{
+		"anonymous":  {"", http.StatusUnauthorized},
+		"other user": {bearer(t, uuid.New(), chirpHandler.JWTSecret), http.StatusNotFound},
+		"uploader":   {bearer(t, uploaderId, chirpHandler.JWTSecret), http.StatusOK},
+	}
+	for name, c := range cases {
+		req := httptest.NewRequest("GET", "/api/media/"+attachmentId.String(), nil)
+		req.SetPathValue("attachmentID", attachmentId.String())
+		if c.authorization != "" {
+			req.Header.Set("Authorization", c.authorization)
+		}
+		respWriter := httptest.NewRecorder()
+		chirpHandler.HandlerGetMedia(respWriter, req)
+		if respWriter.Code != c.code {
+			t.Errorf("%v: expected %v, got %v: %v", name, c.code, respWriter.Code, respWriter.Body)
+			t.FailNow()
+		}
+		if c.code == http.StatusOK && (respWriter.Body.String() != "png!" || respWriter.Header().Get("Cache-Control") != "private, no-store") {
+			t.Errorf("%v: unexpected preview %q with Cache-Control %q.", name, respWriter.Body, respWriter.Header().Get("Cache-Control"))
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/database"
+	"Chirpy/internal/validation"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxMessageLength = 1000
+	// maxConversationMembers includes the user who starts the conversation.
+	maxConversationMembers = 10
+)
+
+type conversationMemberResponse struct {
+	UserId     uuid.UUID  `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+type conversationResponse struct {
+	Id            uuid.UUID                    `json:"id"`
+	IsGroup       bool                         `json:"is_group"`
+	Title         *string                      `json:"title"`
+	CreatedBy     uuid.UUID                    `json:"created_by"`
+	CreatedAt     time.Time                    `json:"created_at"`
+	LastMessageAt time.Time                    `json:"last_message_at"`
+	UnreadCount   *int64                       `json:"unread_count,omitempty"`
+	Members       []conversationMemberResponse `json:"members"`
+}
+
+func newConversationResponse(conversation database.Conversation, members []database.ConversationMember) conversationResponse {
+	resp := conversationResponse{
+		Id:            conversation.ID,
+		IsGroup:       conversation.IsGroup,
+		CreatedBy:     conversation.CreatedBy,
+		CreatedAt:     conversation.CreatedAt,
+		LastMessageAt: conversation.LastMessageAt,
+		Members:       make([]conversationMemberResponse, 0, len(members)),
+	}
+	if conversation.Title.Valid {
+		resp.Title = &conversation.Title.String
+	}
+	for _, member := range members {
+		memberResp := conversationMemberResponse{UserId: member.UserID, JoinedAt: member.JoinedAt}
+		if member.LastReadAt.Valid {
+			memberResp.LastReadAt = &member.LastReadAt.Time
+		}
+		resp.Members = append(resp.Members, memberResp)
+	}
+	return resp
+}
+
+type messageResponse struct {
+	Id             uuid.UUID   `json:"id"`
+	ConversationId uuid.UUID   `json:"conversation_id"`
+	SenderId       uuid.UUID   `json:"sender_id"`
+	Body           string      `json:"body"`
+	CreatedAt      time.Time   `json:"created_at"`
+	ReadBy         []uuid.UUID `json:"read_by"`
+}
+
+// newMessageResponse fills read_by with the other members who have read the
+// conversation up to or past the message.
+func newMessageResponse(message database.Message, members []database.ConversationMember) messageResponse {
+	resp := messageResponse{
+		Id:             message.ID,
+		ConversationId: message.ConversationID,
+		SenderId:       message.SenderID,
+		Body:           message.Body,
+		CreatedAt:      message.CreatedAt,
+		ReadBy:         []uuid.UUID{},
+	}
+	for _, member := range members {
+		if member.UserID != message.SenderID && member.LastReadAt.Valid && !member.LastReadAt.Time.Before(message.CreatedAt) {
+			resp.ReadBy = append(resp.ReadBy, member.UserID)
+		}
+	}
+	return resp
+}
+
+// otherMemberIds drops the caller and repeats from the requested members and
+// checks the conversation is neither empty nor over maxConversationMembers.
+func otherMemberIds(userId uuid.UUID, requested []uuid.UUID) ([]uuid.UUID, error) {
+	memberIds := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{userId: true}
+	for _, memberId := range requested {
+		if !seen[memberId] {
+			seen[memberId] = true
+			memberIds = append(memberIds, memberId)
+		}
+	}
+	if len(memberIds) == 0 {
+		return nil, fmt.Errorf("At least one other member is required.")
+	}
+	if len(memberIds)+1 > maxConversationMembers {
+		return nil, fmt.Errorf("Too many members.")
+	}
+	return memberIds, nil
+}
+
+// cleanMessageBody applies the same cleaning and length counting as chirps.
+func cleanMessageBody(body string) (string, error) {
+	body = validation.Clean(body)
+	if body == "" {
+		return "", fmt.Errorf("Message body is required.")
+	}
+	if validation.Length(body) > maxMessageLength {
+		return "", fmt.Errorf("Message is too long.")
+	}
+	return body, nil
+}
+
+// getConversationForMember looks up the conversation in the path and responds
+// with 404 unless the user is one of its members.
+func (usersHandler *UsersHandler) getConversationForMember(respWriter http.ResponseWriter, req *http.Request, userId uuid.UUID) (database.Conversation, error) {
+	conversationId, err := uuid.Parse(req.PathValue("conversationID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid conversationID.")
+		return database.Conversation{}, err
+	}
+	conversation, err := usersHandler.DB.GetConversationForMember(req.Context(), database.GetConversationForMemberParams{ID: conversationId, UserID: userId})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No conversation found for the given conversationID.")
+			return database.Conversation{}, err
+		}
+		usersHandler.Logger.Printf("Error getting conversation from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return database.Conversation{}, err
+	}
+	return conversation, nil
+}
+
+// HandlerCreateConversation starts a conversation with the given users. A
+// one-to-one conversation is reused if it already exists.
+func (usersHandler *UsersHandler) HandlerCreateConversation(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	reqBody := struct {
+		MemberIds []uuid.UUID `json:"member_ids"`
+		Title     *string     `json:"title"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	memberIds, err := otherMemberIds(userId, reqBody.MemberIds)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	isGroup := len(memberIds) > 1
+	title := sql.NullString{}
+	if reqBody.Title != nil && validation.Clean(*reqBody.Title) != "" {
+		if !isGroup {
+			helpers.RespondWithError(respWriter, 400, "Only group conversations can have a title.")
+			return
+		}
+		title = sql.NullString{String: validation.Clean(*reqBody.Title), Valid: true}
+	}
+	for _, memberId := range memberIds {
+		_, err = usersHandler.DB.GetUser(req.Context(), memberId)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				helpers.RespondWithError(respWriter, 404, "No user found for one of the member_ids.")
+				return
+			}
+			usersHandler.Logger.Printf("Error getting user from db: %v", err)
+			helpers.RespondWithError(respWriter, 500, "Internal server error.")
+			return
+		}
+		blocked, err := hasBlockBetween(req.Context(), usersHandler.DB, userId, memberId)
+		if err != nil {
+			usersHandler.Logger.Printf("Error checking blocks: %v", err)
+			helpers.RespondWithError(respWriter, 500, "Internal server error.")
+			return
+		}
+		if blocked {
+			helpers.RespondWithError(respWriter, 403, "You can't message one of these users.")
+			return
+		}
+	}
+
+	directKey := sql.NullString{}
+	if !isGroup {
+		directKey = directConversationKey(userId, memberIds[0])
+		conversation, err := usersHandler.DB.GetDirectConversation(req.Context(), directKey)
+		if err == nil {
+			usersHandler.respondWithConversation(respWriter, req.Context(), 200, conversation)
+			return
+		}
+		if err != sql.ErrNoRows {
+			usersHandler.Logger.Printf("Error getting conversation from db: %v", err)
+			helpers.RespondWithError(respWriter, 500, "Internal server error.")
+			return
+		}
+	}
+	var conversation database.Conversation
+	err = withTx(req.Context(), usersHandler.ApiConfig, func(qtx *database.Queries) error {
+		if isGroup {
+			conversation, err = qtx.CreateConversation(req.Context(), database.CreateConversationParams{CreatedBy: userId, IsGroup: true, Title: title})
+		} else {
+			conversation, err = qtx.CreateDirectConversation(req.Context(), database.CreateDirectConversationParams{CreatedBy: userId, DirectKey: directKey})
+		}
+		if err != nil {
+			return err
+		}
+		for _, memberId := range append([]uuid.UUID{userId}, memberIds...) {
+			err = qtx.AddConversationMember(req.Context(), database.AddConversationMemberParams{ConversationID: conversation.ID, UserID: memberId})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == sql.ErrNoRows && !isGroup {
+		// A concurrent request created the conversation first.
+		conversation, err = usersHandler.DB.GetDirectConversation(req.Context(), directKey)
+		if err == nil {
+			usersHandler.respondWithConversation(respWriter, req.Context(), 200, conversation)
+			return
+		}
+	}
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to create conversation: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	usersHandler.respondWithConversation(respWriter, req.Context(), 201, conversation)
+}
+
+// directConversationKey identifies the one direct conversation between two
+// users, whichever of them started it.
+func directConversationKey(userId, otherUserId uuid.UUID) sql.NullString {
+	first, second := userId.String(), otherUserId.String()
+	if second < first {
+		first, second = second, first
+	}
+	return sql.NullString{String: first + ":" + second, Valid: true}
+}
+
+func (usersHandler *UsersHandler) respondWithConversation(respWriter http.ResponseWriter, ctx context.Context, code int, conversation database.Conversation) {
+	members, err := usersHandler.DB.GetConversationMembers(ctx, conversation.ID)
+	if err != nil {
+		usersHandler.Logger.Printf("Error getting conversation members from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, code, newConversationResponse(conversation, members))
+}
+
+// HandlerGetConversations lists the user's conversations, most recently
+// active first.
+func (usersHandler *UsersHandler) HandlerGetConversations(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	conversationsPage, err := parsePage(req.URL.Query())
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	rows, err := usersHandler.DB.GetConversationsForUser(req.Context(), database.GetConversationsForUserParams{
+		UserID:     userId,
+		BeforeAt:   conversationsPage.BeforeAt,
+		BeforeID:   conversationsPage.BeforeID,
+		MaxResults: int32(conversationsPage.Limit),
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error getting conversations from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		Conversations []conversationResponse `json:"conversations"`
+		NextCursor    *string                `json:"next_cursor"`
+	}{Conversations: make([]conversationResponse, 0, len(rows))}
+	for _, row := range rows {
+		members, err := usersHandler.DB.GetConversationMembers(req.Context(), row.ID)
+		if err != nil {
+			usersHandler.Logger.Printf("Error getting conversation members from db: %v", err)
+			helpers.RespondWithError(respWriter, 500, "Internal server error.")
+			return
+		}
+		conversationResp := newConversationResponse(database.Conversation{
+			ID:            row.ID,
+			CreatedBy:     row.CreatedBy,
+			IsGroup:       row.IsGroup,
+			Title:         row.Title,
+			CreatedAt:     row.CreatedAt,
+			LastMessageAt: row.LastMessageAt,
+		}, members)
+		conversationResp.UnreadCount = &row.UnreadCount
+		resp.Conversations = append(resp.Conversations, conversationResp)
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = conversationsPage.nextCursor(len(rows), last.LastMessageAt, last.ID)
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (usersHandler *UsersHandler) HandlerGetConversation(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	conversation, err := usersHandler.getConversationForMember(respWriter, req, userId)
+	if err != nil {
+		return
+	}
+	usersHandler.respondWithConversation(respWriter, req.Context(), 200, conversation)
+}
+
+// HandlerSendMessage posts a message to a conversation. Nobody can send to a
+// conversation that includes someone they blocked or who blocked them.
+func (usersHandler *UsersHandler) HandlerSendMessage(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	conversation, err := usersHandler.getConversationForMember(respWriter, req, userId)
+	if err != nil {
+		return
+	}
+	reqBody := struct {
+		Body string `json:"body"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	body, err := cleanMessageBody(reqBody.Body)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	blocked, err := usersHandler.DB.HasBlockInConversation(req.Context(), database.HasBlockInConversationParams{UserID: userId, ConversationID: conversation.ID})
+	if err != nil {
+		usersHandler.Logger.Printf("Error checking blocks: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if blocked {
+		helpers.RespondWithError(respWriter, 403, "You can't message one of the members of this conversation.")
+		return
+	}
+	var message database.Message
+	err = withTx(req.Context(), usersHandler.ApiConfig, func(qtx *database.Queries) error {
+		message, err = qtx.CreateMessage(req.Context(), database.CreateMessageParams{ConversationID: conversation.ID, SenderID: userId, Body: body})
+		if err != nil {
+			return err
+		}
+		return qtx.TouchConversation(req.Context(), database.TouchConversationParams{ID: conversation.ID, LastMessageAt: message.CreatedAt})
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to send message: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 201, newMessageResponse(message, nil))
+}
+
+// HandlerGetMessages pages through a conversation's history, newest first.
+func (usersHandler *UsersHandler) HandlerGetMessages(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	conversation, err := usersHandler.getConversationForMember(respWriter, req, userId)
+	if err != nil {
+		return
+	}
+	messagesPage, err := parsePage(req.URL.Query())
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	messages, err := usersHandler.DB.GetMessages(req.Context(), database.GetMessagesParams{
+		ConversationID: conversation.ID,
+		BeforeAt:       messagesPage.BeforeAt,
+		BeforeID:       messagesPage.BeforeID,
+		MaxResults:     int32(messagesPage.Limit),
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error getting messages from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	members, err := usersHandler.DB.GetConversationMembers(req.Context(), conversation.ID)
+	if err != nil {
+		usersHandler.Logger.Printf("Error getting conversation members from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		Messages   []messageResponse `json:"messages"`
+		NextCursor *string           `json:"next_cursor"`
+	}{Messages: make([]messageResponse, 0, len(messages))}
+	for _, message := range messages {
+		resp.Messages = append(resp.Messages, newMessageResponse(message, members))
+	}
+	if len(messages) > 0 {
+		last := messages[len(messages)-1]
+		resp.NextCursor = messagesPage.nextCursor(len(messages), last.CreatedAt, last.ID)
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+// HandlerMarkConversationRead records that the user has read every message in
+// the conversation so far. Other members see it in read_by and last_read_at.
+func (usersHandler *UsersHandler) HandlerMarkConversationRead(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	conversation, err := usersHandler.getConversationForMember(respWriter, req, userId)
+	if err != nil {
+		return
+	}
+	err = usersHandler.DB.MarkConversationRead(req.Context(), database.MarkConversationReadParams{ConversationID: conversation.ID, UserID: userId})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to mark conversation read: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
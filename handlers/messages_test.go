@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"Chirpy/internal/database"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestOtherMemberIds(t *testing.T) {
+	userId, friendId := uuid.New(), uuid.New()
+	memberIds, err := otherMemberIds(userId, []uuid.UUID{friendId, userId, friendId})
+	if err != nil || len(memberIds) != 1 || memberIds[0] != friendId {
+		t.Errorf("Unexpected members %v, %v", memberIds, err)
+		t.FailNow()
+	}
+	if _, err := otherMemberIds(userId, []uuid.UUID{userId}); err == nil {
+		t.Errorf("Expected a conversation with only yourself to be rejected.")
+		t.FailNow()
+	}
+	full := []uuid.UUID{}
+	for len(full) < maxConversationMembers-1 {
+		full = append(full, uuid.New())
+	}
+	if _, err := otherMemberIds(userId, full); err != nil {
+		t.Errorf("Expected %v members to be allowed: %v", maxConversationMembers, err)
+		t.FailNow()
+	}
+	if _, err := otherMemberIds(userId, append(full, uuid.New())); err == nil {
+		t.Errorf("Expected more than %v members to be rejected.", maxConversationMembers)
+		t.FailNow()
+	}
+}
+
+func TestCleanMessageBody(t *testing.T) {
+	body, err := cleanMessageBody("  hi there  ")
+	if err != nil || body != "hi there" {
+		t.Errorf("Unexpected body %q, %v", body, err)
+		t.FailNow()
+	}
+	if _, err := cleanMessageBody(strings.Repeat("é", maxMessageLength)); err != nil {
+		t.Errorf("Expected %v characters to be allowed: %v", maxMessageLength, err)
+		t.FailNow()
+	}
+	// A family emoji is one character however many code points it joins.
+	family := "\U0001F468\u200d\U0001F469\u200d\U0001F467"
+	if _, err := cleanMessageBody(strings.Repeat(family, maxMessageLength)); err != nil {
+		t.Errorf("Expected %v emoji to be allowed: %v", maxMessageLength, err)
+		t.FailNow()
+	}
+	body, err = cleanMessageBody("\u200bhi\u0000 ")
+	if err != nil || body != "hi" {
+		t.Errorf("Expected invisible characters to be stripped, got %q, %v", body, err)
+		t.FailNow()
+	}
+	for _, invalid := range []string{"   ", "\u200b\u200b", strings.Repeat("a", maxMessageLength+1)} {
+		if _, err := cleanMessageBody(invalid); err == nil {
+			t.Errorf("Expected a body of %v bytes to be rejected.", len(invalid))
+			t.FailNow()
+		}
+	}
+}
+
+func TestDirectConversationKey(t *testing.T) {
+	userId, friendId := uuid.New(), uuid.New()
+	if directConversationKey(userId, friendId) != directConversationKey(friendId, userId) {
+		t.Errorf("Expected the key not to depend on who started the conversation.")
+		t.FailNow()
+	}
+	if directConversationKey(userId, friendId) == directConversationKey(userId, uuid.New()) {
+		t.Errorf("Expected different pairs to have different keys.")
+		t.FailNow()
+	}
+}
+
+func TestMessageReadBy(t *testing.T) {
+	sentAt := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	senderId, readerId, behindId, unreadId := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	message := database.Message{ID: uuid.New(), SenderID: senderId, Body: "hi", CreatedAt: sentAt}
+	members := []database.ConversationMember{
+		{UserID: senderId, LastReadAt: sql.NullTime{Time: sentAt.Add(time.Hour), Valid: true}},
+		{UserID: readerId, LastReadAt: sql.NullTime{Time: sentAt, Valid: true}},
+		{UserID: behindId, LastReadAt: sql.NullTime{Time: sentAt.Add(-time.Second), Valid: true}},
+		{UserID: unreadId},
+	}
+	resp := newMessageResponse(message, members)
+	if len(resp.ReadBy) != 1 || resp.ReadBy[0] != readerId {
+		t.Errorf("Expected only %v in read_by, got %v.", readerId, resp.ReadBy)
+		t.FailNow()
+	}
+}
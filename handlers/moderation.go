@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/config"
+	"Chirpy/internal/database"
+	"Chirpy/internal/moderation"
+	"Chirpy/internal/outbox"
+	"Chirpy/internal/stream"
+	"Chirpy/internal/validation"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ModerationHandler struct {
+	*config.ApiConfig
+}
+
+type moderationQueueItemResponse struct {
+	ChirpId         uuid.UUID      `json:"chirp_id"`
+	AuthorId        uuid.UUID      `json:"author_id"`
+	Body            string         `json:"body"`
+	Hidden          bool           `json:"hidden"`
+	ReportCount     int64          `json:"report_count"`
+	Reasons         map[string]int `json:"reasons"`
+	FirstReportedAt time.Time      `json:"first_reported_at"`
+	LastReportedAt  time.Time      `json:"last_reported_at"`
+}
+
+type moderationActionResponse struct {
+	Id              uuid.UUID  `json:"id"`
+	ModeratorId     *uuid.UUID `json:"moderator_id"`
+	Action          string     `json:"action"`
+	ChirpId         *uuid.UUID `json:"chirp_id"`
+	TargetUserId    *uuid.UUID `json:"target_user_id"`
+	Note            *string    `json:"note"`
+	ReportsResolved int32      `json:"reports_resolved"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func newModerationActionResponse(action database.ModerationAction) moderationActionResponse {
+	resp := moderationActionResponse{
+		Id:              action.ID,
+		Action:          action.Action,
+		ReportsResolved: action.ReportsResolved,
+		CreatedAt:       action.CreatedAt,
+	}
+	if action.ModeratorID.Valid {
+		resp.ModeratorId = &action.ModeratorID.UUID
+	}
+	if action.ChirpID.Valid {
+		resp.ChirpId = &action.ChirpID.UUID
+	}
+	if action.TargetUserID.Valid {
+		resp.TargetUserId = &action.TargetUserID.UUID
+	}
+	if action.Note.Valid {
+		resp.Note = &action.Note.String
+	}
+	return resp
+}
+
+// HandlerGetModerationQueue lists chirps with pending reports, most reported
+// first and then oldest first. It is not paged: the order shifts as reports
+// come in, so it returns the top limit chirps.
+func (moderationHandler *ModerationHandler) HandlerGetModerationQueue(respWriter http.ResponseWriter, req *http.Request) {
+	_, err := authenticateModerator(moderationHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	limit, err := parseLimit(req.URL.Query())
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	rows, err := moderationHandler.DB.GetModerationQueue(req.Context(), int32(limit))
+	if err != nil {
+		moderationHandler.Logger.Printf("Error getting moderation queue from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := make([]moderationQueueItemResponse, 0, len(rows))
+	for _, row := range rows {
+		resp = append(resp, moderationQueueItemResponse{
+			ChirpId:         row.ChirpID,
+			AuthorId:        row.AuthorID,
+			Body:            row.Body,
+			Hidden:          row.HiddenAt.Valid,
+			ReportCount:     row.ReportCount,
+			Reasons:         moderation.CountReasons(row.Reasons),
+			FirstReportedAt: row.FirstReportedAt,
+			LastReportedAt:  row.LastReportedAt,
+		})
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (moderationHandler *ModerationHandler) HandlerGetChirpReports(respWriter http.ResponseWriter, req *http.Request) {
+	_, err := authenticateModerator(moderationHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid chirpID.")
+		return
+	}
+	reports, err := moderationHandler.DB.GetPendingReportsForChirp(req.Context(), chirpId)
+	if err != nil {
+		moderationHandler.Logger.Printf("Error getting reports from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := make([]reportResponse, 0, len(reports))
+	for _, report := range reports {
+		resp = append(resp, newReportResponse(report))
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+// HandlerModerateChirp applies a moderator's decision to a reported chirp,
+// resolves its pending reports and records the decision in the audit trail,
+// all in one transaction.
+func (moderationHandler *ModerationHandler) HandlerModerateChirp(respWriter http.ResponseWriter, req *http.Request) {
+	moderatorId, err := authenticateModerator(moderationHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid chirpID.")
+		return
+	}
+	reqBody := struct {
+		Action        string `json:"action"`
+		Note          string `json:"note"`
+		DurationHours *int   `json:"duration_hours"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	err = moderation.ValidateAction(reqBody.Action)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	suspendedUntil, err := moderation.SuspensionEnd(reqBody.DurationHours, time.Now())
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	note := validation.Clean(reqBody.Note)
+	chirp, err := moderationHandler.DB.GetOneChirp(req.Context(), chirpId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
+			return
+		}
+		moderationHandler.Logger.Printf("Error getting chirp from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+
+	var action database.ModerationAction
+	err = withTx(req.Context(), moderationHandler.ApiConfig, func(qtx *database.Queries) error {
+		// Resolve first: deleting the chirp also deletes its reports.
+		resolved, err := qtx.ResolveReportsForChirp(req.Context(), chirp.ID)
+		if err != nil {
+			return err
+		}
+		switch reqBody.Action {
+		case moderation.ActionHide:
+			_, err = qtx.HideChirp(req.Context(), chirp.ID)
+			if err == nil {
+				err = outbox.Record(req.Context(), qtx, outbox.EventChirpDeleted, chirp.UserID, newChirpResponse(chirp))
+			}
+		case moderation.ActionDelete:
+			_, err = qtx.DeleteChirp(req.Context(), chirp.ID)
+			if err == nil {
+				err = outbox.Record(req.Context(), qtx, outbox.EventChirpDeleted, chirp.UserID, newChirpResponse(chirp))
+			}
+		case moderation.ActionSuspend:
+			_, err = suspendUser(req.Context(), qtx, chirp.UserID, suspendedUntil, note)
+		}
+		if err != nil {
+			return err
+		}
+		action, err = qtx.CreateModerationAction(req.Context(), database.CreateModerationActionParams{
+			ModeratorID:     moderatorId,
+			Action:          reqBody.Action,
+			ChirpID:         uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			TargetUserID:    uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+			Note:            sql.NullString{String: note, Valid: note != ""},
+			ReportsResolved: int32(resolved),
+		})
+		return err
+	})
+	if err != nil {
+		moderationHandler.Logger.Printf("Error trying to %v chirp %v: %v", reqBody.Action, chirp.ID, err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	// A hidden chirp is gone for everyone but moderators, so listeners see it
+	// deleted either way.
+	if reqBody.Action == moderation.ActionHide || reqBody.Action == moderation.ActionDelete {
+		chirpHanlder := ChirpHandler{ApiConfig: moderationHandler.ApiConfig}
+		chirpHanlder.publishChirpEvent(stream.EventChirpDeleted, newChirpResponse(chirp))
+	}
+	helpers.RespondWithJson(respWriter, 201, newModerationActionResponse(action))
+}
+
+// HandlerGetModerationActions pages through the audit trail of moderator
+// decisions, newest first.
+func (moderationHandler *ModerationHandler) HandlerGetModerationActions(respWriter http.ResponseWriter, req *http.Request) {
+	_, err := authenticateModerator(moderationHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	actionsPage, err := parsePage(req.URL.Query())
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	actions, err := moderationHandler.DB.GetModerationActions(req.Context(), database.GetModerationActionsParams{
+		BeforeAt:   actionsPage.BeforeAt,
+		BeforeID:   actionsPage.BeforeID,
+		MaxResults: int32(actionsPage.Limit),
+	})
+	if err != nil {
+		moderationHandler.Logger.Printf("Error getting moderation actions from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		Actions    []moderationActionResponse `json:"actions"`
+		NextCursor *string                    `json:"next_cursor"`
+	}{Actions: make([]moderationActionResponse, 0, len(actions))}
+	for _, action := range actions {
+		resp.Actions = append(resp.Actions, newModerationActionResponse(action))
+	}
+	if len(actions) > 0 {
+		last := actions[len(actions)-1]
+		resp.NextCursor = actionsPage.nextCursor(len(actions), last.CreatedAt, last.ID)
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+// HandlerAddModerator lets the admin make a user a moderator.
+func (moderationHandler *ModerationHandler) HandlerAddModerator(respWriter http.ResponseWriter, req *http.Request) {
+	if authenticateAdmin(moderationHandler.ApiConfig, respWriter, req) != nil {
+		return
+	}
+	userId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid userID.")
+		return
+	}
+	_, err = moderationHandler.DB.GetUser(req.Context(), userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No user found for the given userID.")
+			return
+		}
+		moderationHandler.Logger.Printf("Error getting user from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	_, err = moderationHandler.DB.AddModerator(req.Context(), userId)
+	if err != nil {
+		moderationHandler.Logger.Printf("Error trying to add moderator: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+func (moderationHandler *ModerationHandler) HandlerRemoveModerator(respWriter http.ResponseWriter, req *http.Request) {
+	if authenticateAdmin(moderationHandler.ApiConfig, respWriter, req) != nil {
+		return
+	}
+	userId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid userID.")
+		return
+	}
+	removed, err := moderationHandler.DB.RemoveModerator(req.Context(), userId)
+	if err != nil {
+		moderationHandler.Logger.Printf("Error trying to remove moderator: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if removed == 0 {
+		helpers.RespondWithError(respWriter, 404, "This user is not a moderator.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+func (moderationHandler *ModerationHandler) HandlerGetModerators(respWriter http.ResponseWriter, req *http.Request) {
+	if authenticateAdmin(moderationHandler.ApiConfig, respWriter, req) != nil {
+		return
+	}
+	moderators, err := moderationHandler.DB.GetModerators(req.Context())
+	if err != nil {
+		moderationHandler.Logger.Printf("Error getting moderators from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := make([]relationshipResponse, 0, len(moderators))
+	for _, moderator := range moderators {
+		resp = append(resp, relationshipResponse{UserId: moderator.UserID, CreatedAt: moderator.CreatedAt})
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
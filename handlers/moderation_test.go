@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"Chirpy/internal/database"
+	"Chirpy/internal/stream"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func fakeModerationAction(action string, chirp database.Chirp) fakeResult {
+	return fakeResult{
+		columns: []string{"id", "moderator_id", "action", "chirp_id", "target_user_id", "note", "reports_resolved", "created_at"},
+		rows:    [][]driver.Value{{uuid.New().String(), nil, action, chirp.ID.String(), chirp.UserID.String(), nil, int64(1), time.Now()}},
+	}
+}
+
+func TestHideChirpIsPublishedAsDeleted(t *testing.T) {
+	moderatorId, authorId := uuid.New(), uuid.New()
+	chirp := database.Chirp{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Body: "spam", UserID: authorId, Published: true}
+	db := newFakeDB()
+	db.set("IsModerator", fakeValue(true))
+	db.set("GetOneChirp", fakeChirp(chirp))
+	db.set("ResolveReportsForChirp", fakeResult{rowsAffected: 1})
+	db.set("HideChirp", fakeChirp(chirp))
+	db.set("CreateOutboxEvent", fakeOutboxEvent(authorId))
+	db.set("CreateModerationAction", fakeModerationAction("hide", chirp))
+
+	moderationHandler := ModerationHandler{db.apiConfig()}
+	req := httptest.NewRequest("POST", "/api/moderation/chirps/"+chirp.ID.String()+"/actions", strings.NewReader(`{"action": "hide"}`))
+	req.SetPathValue("chirpID", chirp.ID.String())
+	req.Header.Set("Authorization", bearer(t, moderatorId, moderationHandler.JWTSecret))
+	respWriter := httptest.NewRecorder()
+	moderationHandler.HandlerModerateChirp(respWriter, req)
+	if respWriter.Code != http.StatusCreated {
+		t.Errorf("Expected 201, got %v: %v", respWriter.Code, respWriter.Body)
+		t.FailNow()
+	}
+	events := db.called("CreateOutboxEvent")
+	if len(events) != 1 || events[0].args[0] != "chirp.deleted" || events[0].args[1] != authorId.String() || !events[0].tx.committed {
+		t.Errorf("Expected a committed chirp.deleted event for the author, got %+v.", events)
+		t.FailNow()
+	}
+	_, published := moderationHandler.Stream.Subscribe(1, nil)
+	if len(published) != 1 || published[0].Type != stream.EventChirpDeleted || published[0].AuthorID != authorId {
+		t.Errorf("Expected a chirp.deleted stream event, got %+v.", published)
+		t.FailNow()
+	}
+}
+
+func TestModerationQueueIsNotPaged(t *testing.T) {
+	db := newFakeDB()
+	db.set("IsModerator", fakeValue(true))
+	db.empty("GetModerationQueue")
+	moderationHandler := ModerationHandler{db.apiConfig()}
+	for rawQuery, code := range map[string]int{"limit=5": 200, "limit=0": 400} {
+		req := httptest.NewRequest("GET", "/api/moderation/reports?"+rawQuery, nil)
+		req.Header.Set("Authorization", bearer(t, uuid.New(), moderationHandler.JWTSecret))
+		respWriter := httptest.NewRecorder()
+		moderationHandler.HandlerGetModerationQueue(respWriter, req)
+		if respWriter.Code != code {
+			t.Errorf("Expected %v for %q, got %v: %v", code, rawQuery, respWriter.Code, respWriter.Body)
+			t.FailNow()
+		}
+	}
+	queries := db.called("GetModerationQueue")
+	if len(queries) != 1 || queries[0].args[0] != int64(5) {
+		t.Errorf("Expected one queue query with limit 5, got %+v.", queries)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/database"
+	"Chirpy/internal/notifications"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// HandlerGetNotifications lists the user's notifications newest first. Pass
+// next_cursor from the previous page as ?cursor= to get the next one.
+func (usersHandler *UsersHandler) HandlerGetNotifications(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	query := req.URL.Query()
+	notificationsPage, err := parsePage(query)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	rows, err := usersHandler.DB.GetNotifications(req.Context(), database.GetNotificationsParams{
+		UserID:     userId,
+		BeforeAt:   notificationsPage.BeforeAt,
+		BeforeID:   notificationsPage.BeforeID,
+		UnreadOnly: query.Get("unread") == "true",
+		MaxResults: int32(notificationsPage.Limit),
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to get notifications: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		Notifications []notifications.Response `json:"notifications"`
+		NextCursor    *string                  `json:"next_cursor"`
+	}{Notifications: make([]notifications.Response, 0, len(rows))}
+	for _, row := range rows {
+		resp.Notifications = append(resp.Notifications, notifications.NewResponse(row))
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		resp.NextCursor = notificationsPage.nextCursor(len(rows), last.CreatedAt, last.ID)
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (usersHandler *UsersHandler) HandlerGetUnreadNotificationCount(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	unread, err := usersHandler.DB.CountUnreadNotifications(req.Context(), userId)
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to count unread notifications: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 200, struct {
+		Unread int64 `json:"unread"`
+	}{Unread: unread})
+}
+
+func (usersHandler *UsersHandler) HandlerMarkNotificationRead(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	notificationId, err := uuid.Parse(req.PathValue("notificationID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid notificationID.")
+		return
+	}
+	updated, err := usersHandler.DB.MarkNotificationRead(req.Context(), database.MarkNotificationReadParams{ID: notificationId, UserID: userId})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to mark notification read: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if updated == 0 {
+		helpers.RespondWithError(respWriter, 404, "No notification found for the given notificationID.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+func (usersHandler *UsersHandler) HandlerMarkAllNotificationsRead(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	_, err = usersHandler.DB.MarkAllNotificationsRead(req.Context(), userId)
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to mark notifications read: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+type notificationPreferencesResponse struct {
+	Mentions  bool `json:"mentions"`
+	Replies   bool `json:"replies"`
+	Follows   bool `json:"follows"`
+	Likes     bool `json:"likes"`
+	ChirpyRed bool `json:"chirpy_red"`
+}
+
+func newNotificationPreferencesResponse(preferences database.NotificationPreference) notificationPreferencesResponse {
+	return notificationPreferencesResponse{
+		Mentions:  preferences.Mentions,
+		Replies:   preferences.Replies,
+		Follows:   preferences.Follows,
+		Likes:     preferences.Likes,
+		ChirpyRed: preferences.ChirpyRed,
+	}
+}
+
+func (usersHandler *UsersHandler) getNotificationPreferences(req *http.Request, userId uuid.UUID) (database.NotificationPreference, error) {
+	preferences, err := usersHandler.DB.GetNotificationPreferences(req.Context(), userId)
+	if err == sql.ErrNoRows {
+		return notifications.DefaultPreferences(userId), nil
+	}
+	return preferences, err
+}
+
+func (usersHandler *UsersHandler) HandlerGetNotificationPreferences(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	preferences, err := usersHandler.getNotificationPreferences(req, userId)
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to get notification preferences: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 200, newNotificationPreferencesResponse(preferences))
+}
+
+// HandlerUpdateNotificationPreferences changes only the fields present in the
+// request body.
+func (usersHandler *UsersHandler) HandlerUpdateNotificationPreferences(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	preferences, err := usersHandler.getNotificationPreferences(req, userId)
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to get notification preferences: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	reqBody := newNotificationPreferencesResponse(preferences)
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	preferences, err = usersHandler.DB.UpsertNotificationPreferences(req.Context(), database.UpsertNotificationPreferencesParams{
+		UserID:    userId,
+		Mentions:  reqBody.Mentions,
+		Replies:   reqBody.Replies,
+		Follows:   reqBody.Follows,
+		Likes:     reqBody.Likes,
+		ChirpyRed: reqBody.ChirpyRed,
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to update notification preferences: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 200, newNotificationPreferencesResponse(preferences))
+}
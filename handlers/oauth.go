@@ -0,0 +1,496 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/config"
+	"Chirpy/internal/database"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	oauthCodeExpiry        = 10 * time.Minute
+	oauthAccessTokenExpiry = time.Hour
+)
+
+type OAuthHandler struct {
+	*config.ApiConfig
+}
+
+type authorizeRequest struct {
+	client      database.OauthClient
+	redirectUri string
+	scopes      []string
+	state       string
+	challenge   string
+}
+
+func (oauthHandler *OAuthHandler) HandlerRegisterClient(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateJWT(oauthHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	reqBody := struct {
+		Name         string   `json:"name"`
+		RedirectUris []string `json:"redirect_uris"`
+		Confidential bool     `json:"confidential"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	if reqBody.Name == "" {
+		helpers.RespondWithError(respWriter, 400, "Name cannot be empty.")
+		return
+	}
+	if len(reqBody.RedirectUris) == 0 {
+		helpers.RespondWithError(respWriter, 400, "At least one redirect_uri is required.")
+		return
+	}
+	for _, redirectUri := range reqBody.RedirectUris {
+		err = validateRedirectUri(redirectUri)
+		if err != nil {
+			helpers.RespondWithError(respWriter, 400, err.Error())
+			return
+		}
+	}
+	secret := ""
+	secretHash := sql.NullString{}
+	if reqBody.Confidential {
+		secret = auth.MakeRefreshToken()
+		secretHash = sql.NullString{String: auth.HashToken(secret), Valid: true}
+	}
+	client, err := oauthHandler.DB.CreateOAuthClient(req.Context(), database.CreateOAuthClientParams{
+		OwnerID:      userId,
+		Name:         reqBody.Name,
+		SecretHash:   secretHash,
+		RedirectUris: reqBody.RedirectUris,
+	})
+	if err != nil {
+		oauthHandler.Logger.Printf("Error trying to create oauth client: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		ClientId     uuid.UUID `json:"client_id"`
+		ClientSecret string    `json:"client_secret,omitempty"`
+		Name         string    `json:"name"`
+		RedirectUris []string  `json:"redirect_uris"`
+		CreatedAt    time.Time `json:"created_at"`
+	}{
+		ClientId:     client.ID,
+		ClientSecret: secret,
+		Name:         client.Name,
+		RedirectUris: client.RedirectUris,
+		CreatedAt:    client.CreatedAt,
+	}
+	helpers.RespondWithJson(respWriter, 201, resp)
+}
+
+func validateRedirectUri(redirectUri string) error {
+	parsed, err := url.Parse(redirectUri)
+	if err != nil || !parsed.IsAbs() || parsed.Host == "" {
+		return fmt.Errorf("Invalid redirect_uri: %v", redirectUri)
+	}
+	if parsed.Fragment != "" {
+		return fmt.Errorf("redirect_uri must not contain a fragment: %v", redirectUri)
+	}
+	if parsed.Scheme == "https" {
+		return nil
+	}
+	if parsed.Scheme == "http" && (parsed.Hostname() == "localhost" || parsed.Hostname() == "127.0.0.1") {
+		return nil
+	}
+	return fmt.Errorf("redirect_uri must use https: %v", redirectUri)
+}
+
+// parseAuthorizeRequest validates the query of an authorization request. Errors
+// are returned to the user agent instead of being redirected, since the client
+// and redirect_uri can't be trusted until they have been checked.
+func (oauthHandler *OAuthHandler) parseAuthorizeRequest(respWriter http.ResponseWriter, req *http.Request) (authorizeRequest, error) {
+	query := req.URL.Query()
+	authReq := authorizeRequest{state: query.Get("state"), challenge: query.Get("code_challenge")}
+	clientId, err := uuid.Parse(query.Get("client_id"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid client_id.")
+		return authReq, err
+	}
+	authReq.client, err = oauthHandler.DB.GetOAuthClient(req.Context(), clientId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 400, "Unknown client_id.")
+			return authReq, err
+		}
+		oauthHandler.Logger.Printf("Error trying to get oauth client: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return authReq, err
+	}
+	authReq.redirectUri = query.Get("redirect_uri")
+	if authReq.redirectUri == "" && len(authReq.client.RedirectUris) == 1 {
+		authReq.redirectUri = authReq.client.RedirectUris[0]
+	}
+	if !slices.Contains(authReq.client.RedirectUris, authReq.redirectUri) {
+		helpers.RespondWithError(respWriter, 400, "redirect_uri is not registered for this client.")
+		return authReq, fmt.Errorf("Unregistered redirect_uri")
+	}
+	if query.Get("response_type") != "code" {
+		helpers.RespondWithError(respWriter, 400, "response_type must be code.")
+		return authReq, fmt.Errorf("Unsupported response_type")
+	}
+	authReq.scopes = auth.ParseScopes(query.Get("scope"))
+	err = auth.ValidateScopes(authReq.scopes)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return authReq, err
+	}
+	if authReq.challenge == "" || query.Get("code_challenge_method") != "S256" {
+		helpers.RespondWithError(respWriter, 400, "PKCE is required. Send code_challenge with code_challenge_method S256.")
+		return authReq, fmt.Errorf("Missing PKCE challenge")
+	}
+	return authReq, nil
+}
+
+func (oauthHandler *OAuthHandler) HandlerGetAuthorize(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateJWT(oauthHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	authReq, err := oauthHandler.parseAuthorizeRequest(respWriter, req)
+	if err != nil {
+		return
+	}
+	consentGranted := false
+	consent, err := oauthHandler.DB.GetOAuthConsent(req.Context(), database.GetOAuthConsentParams{UserID: userId, ClientID: authReq.client.ID})
+	if err != nil && err != sql.ErrNoRows {
+		oauthHandler.Logger.Printf("Error trying to get oauth consent: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if err == nil {
+		consentGranted = true
+		for _, scope := range authReq.scopes {
+			if !auth.HasScope(consent.Scopes, scope) {
+				consentGranted = false
+			}
+		}
+	}
+	resp := struct {
+		ClientId       uuid.UUID `json:"client_id"`
+		ClientName     string    `json:"client_name"`
+		RedirectUri    string    `json:"redirect_uri"`
+		Scopes         []string  `json:"scopes"`
+		ConsentGranted bool      `json:"consent_granted"`
+	}{
+		ClientId:       authReq.client.ID,
+		ClientName:     authReq.client.Name,
+		RedirectUri:    authReq.redirectUri,
+		Scopes:         authReq.scopes,
+		ConsentGranted: consentGranted,
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (oauthHandler *OAuthHandler) HandlerPostAuthorize(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateJWT(oauthHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	authReq, err := oauthHandler.parseAuthorizeRequest(respWriter, req)
+	if err != nil {
+		return
+	}
+	reqBody := struct {
+		Approve bool `json:"approve"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	redirectParams := url.Values{}
+	if authReq.state != "" {
+		redirectParams.Set("state", authReq.state)
+	}
+	if !reqBody.Approve {
+		redirectParams.Set("error", "access_denied")
+		respondWithRedirect(respWriter, authReq.redirectUri, redirectParams)
+		return
+	}
+	_, err = oauthHandler.DB.UpsertOAuthConsent(req.Context(), database.UpsertOAuthConsentParams{
+		UserID:   userId,
+		ClientID: authReq.client.ID,
+		Scopes:   authReq.scopes,
+	})
+	if err != nil {
+		oauthHandler.Logger.Printf("Error trying to save oauth consent: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	code := auth.MakeRefreshToken()
+	_, err = oauthHandler.DB.CreateOAuthAuthorizationCode(req.Context(), database.CreateOAuthAuthorizationCodeParams{
+		CodeHash:      auth.HashToken(code),
+		ClientID:      authReq.client.ID,
+		UserID:        userId,
+		RedirectUri:   authReq.redirectUri,
+		Scopes:        authReq.scopes,
+		CodeChallenge: authReq.challenge,
+		ExpiresAt:     time.Now().Add(oauthCodeExpiry),
+	})
+	if err != nil {
+		oauthHandler.Logger.Printf("Error trying to create authorization code: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	redirectParams.Set("code", code)
+	respondWithRedirect(respWriter, authReq.redirectUri, redirectParams)
+}
+
+// respondWithRedirect hands the final redirect back to the first party client,
+// which navigates the user agent to it.
+func respondWithRedirect(respWriter http.ResponseWriter, redirectUri string, params url.Values) {
+	target, _ := url.Parse(redirectUri)
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	target.RawQuery = query.Encode()
+	resp := struct {
+		RedirectTo string `json:"redirect_to"`
+	}{RedirectTo: target.String()}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (oauthHandler *OAuthHandler) HandlerToken(respWriter http.ResponseWriter, req *http.Request) {
+	err := req.ParseForm()
+	if err != nil {
+		respondWithOAuthError(respWriter, 400, "invalid_request", "Unable to parse form body.")
+		return
+	}
+	client, err := oauthHandler.authenticateClient(req)
+	if err != nil {
+		respondWithOAuthError(respWriter, 401, "invalid_client", err.Error())
+		return
+	}
+	if req.PostForm.Get("grant_type") != "authorization_code" {
+		respondWithOAuthError(respWriter, 400, "unsupported_grant_type", "Only authorization_code is supported.")
+		return
+	}
+	code, err := oauthHandler.DB.ConsumeOAuthAuthorizationCode(req.Context(), auth.HashToken(req.PostForm.Get("code")))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithOAuthError(respWriter, 400, "invalid_grant", "Invalid authorization code.")
+			return
+		}
+		oauthHandler.Logger.Printf("Error trying to consume authorization code: %v", err)
+		respondWithOAuthError(respWriter, 500, "server_error", "Internal server error.")
+		return
+	}
+	if code.ClientID != client.ID || code.RedirectUri != req.PostForm.Get("redirect_uri") {
+		respondWithOAuthError(respWriter, 400, "invalid_grant", "Authorization code was issued to another client or redirect_uri.")
+		return
+	}
+	if time.Now().After(code.ExpiresAt) {
+		respondWithOAuthError(respWriter, 400, "invalid_grant", "Authorization code expired.")
+		return
+	}
+	if !auth.VerifyPKCE(req.PostForm.Get("code_verifier"), code.CodeChallenge) {
+		respondWithOAuthError(respWriter, 400, "invalid_grant", "Invalid code_verifier.")
+		return
+	}
+	token := auth.MakeAccessToken()
+	accessToken, err := oauthHandler.DB.CreateOAuthAccessToken(req.Context(), database.CreateOAuthAccessTokenParams{
+		TokenHash: auth.HashToken(token),
+		ClientID:  client.ID,
+		UserID:    code.UserID,
+		Scopes:    code.Scopes,
+		ExpiresAt: time.Now().Add(oauthAccessTokenExpiry),
+	})
+	if err != nil {
+		oauthHandler.Logger.Printf("Error trying to create access token: %v", err)
+		respondWithOAuthError(respWriter, 500, "server_error", "Internal server error.")
+		return
+	}
+	resp := struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+		Scope       string `json:"scope"`
+	}{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(oauthAccessTokenExpiry.Seconds()),
+		Scope:       auth.FormatScopes(accessToken.Scopes),
+	}
+	respondWithOAuthJson(respWriter, 200, resp)
+}
+
+func (oauthHandler *OAuthHandler) HandlerIntrospect(respWriter http.ResponseWriter, req *http.Request) {
+	err := req.ParseForm()
+	if err != nil {
+		respondWithOAuthError(respWriter, 400, "invalid_request", "Unable to parse form body.")
+		return
+	}
+	client, err := oauthHandler.authenticateClient(req)
+	if err != nil || !client.SecretHash.Valid {
+		respondWithOAuthError(respWriter, 401, "invalid_client", "Introspection requires a confidential client.")
+		return
+	}
+	inactive := struct {
+		Active bool `json:"active"`
+	}{Active: false}
+	accessToken, err := oauthHandler.DB.GetOAuthAccessToken(req.Context(), auth.HashToken(req.PostForm.Get("token")))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithOAuthJson(respWriter, 200, inactive)
+			return
+		}
+		oauthHandler.Logger.Printf("Error trying to get access token: %v", err)
+		respondWithOAuthError(respWriter, 500, "server_error", "Internal server error.")
+		return
+	}
+	if accessToken.ClientID != client.ID || accessToken.RevokedAt.Valid || time.Now().After(accessToken.ExpiresAt) {
+		respondWithOAuthJson(respWriter, 200, inactive)
+		return
+	}
+	resp := struct {
+		Active    bool      `json:"active"`
+		Scope     string    `json:"scope"`
+		ClientId  uuid.UUID `json:"client_id"`
+		Sub       uuid.UUID `json:"sub"`
+		TokenType string    `json:"token_type"`
+		Exp       int64     `json:"exp"`
+		Iat       int64     `json:"iat"`
+	}{
+		Active:    true,
+		Scope:     auth.FormatScopes(accessToken.Scopes),
+		ClientId:  accessToken.ClientID,
+		Sub:       accessToken.UserID,
+		TokenType: "Bearer",
+		Exp:       accessToken.ExpiresAt.Unix(),
+		Iat:       accessToken.CreatedAt.Unix(),
+	}
+	respondWithOAuthJson(respWriter, 200, resp)
+}
+
+// authenticateClient accepts client credentials through HTTP Basic auth or the
+// form body. Public clients only have to identify themselves.
+func (oauthHandler *OAuthHandler) authenticateClient(req *http.Request) (database.OauthClient, error) {
+	clientIdValue, secret, ok := req.BasicAuth()
+	if !ok {
+		clientIdValue = req.PostForm.Get("client_id")
+		secret = req.PostForm.Get("client_secret")
+	}
+	clientId, err := uuid.Parse(clientIdValue)
+	if err != nil {
+		return database.OauthClient{}, fmt.Errorf("Invalid client_id.")
+	}
+	client, err := oauthHandler.DB.GetOAuthClient(req.Context(), clientId)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			oauthHandler.Logger.Printf("Error trying to get oauth client: %v", err)
+		}
+		return database.OauthClient{}, fmt.Errorf("Unknown client.")
+	}
+	if client.SecretHash.Valid && subtle.ConstantTimeCompare([]byte(auth.HashToken(secret)), []byte(client.SecretHash.String)) != 1 {
+		return database.OauthClient{}, fmt.Errorf("Invalid client credentials.")
+	}
+	return client, nil
+}
+
+func (oauthHandler *OAuthHandler) HandlerGetConsents(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateJWT(oauthHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	consents, err := oauthHandler.DB.GetOAuthConsentsForUser(req.Context(), userId)
+	if err != nil {
+		oauthHandler.Logger.Printf("Error trying to get oauth consents: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	type consentResponse struct {
+		ClientId   uuid.UUID `json:"client_id"`
+		ClientName string    `json:"client_name"`
+		Scopes     []string  `json:"scopes"`
+		CreatedAt  time.Time `json:"created_at"`
+		UpdatedAt  time.Time `json:"updated_at"`
+	}
+	resp := make([]consentResponse, 0, len(consents))
+	for _, consent := range consents {
+		resp = append(resp, consentResponse{
+			ClientId:   consent.ClientID,
+			ClientName: consent.Name,
+			Scopes:     consent.Scopes,
+			CreatedAt:  consent.CreatedAt,
+			UpdatedAt:  consent.UpdatedAt,
+		})
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (oauthHandler *OAuthHandler) HandlerRevokeConsent(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateJWT(oauthHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	clientId, err := uuid.Parse(req.PathValue("clientID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid clientID.")
+		return
+	}
+	_, err = oauthHandler.DB.DeleteOAuthConsent(req.Context(), database.DeleteOAuthConsentParams{UserID: userId, ClientID: clientId})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No consent found for the given clientID.")
+			return
+		}
+		oauthHandler.Logger.Printf("Error trying to delete oauth consent: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	err = oauthHandler.DB.RevokeOAuthAccessTokens(req.Context(), database.RevokeOAuthAccessTokensParams{UserID: userId, ClientID: clientId})
+	if err != nil {
+		oauthHandler.Logger.Printf("Error trying to revoke oauth access tokens: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+// The token and introspection endpoints answer with bare JSON as required by
+// RFC 6749 and RFC 7662 instead of the usual body envelope.
+func respondWithOAuthJson(respWriter http.ResponseWriter, code int, payload any) {
+	respJson, err := json.Marshal(payload)
+	if err != nil {
+		respondWithOAuthError(respWriter, 500, "server_error", "Internal server error.")
+		return
+	}
+	respWriter.Header().Set("Content-Type", "application/json")
+	respWriter.Header().Set("Cache-Control", "no-store")
+	respWriter.WriteHeader(code)
+	respWriter.Write(respJson)
+}
+
+func respondWithOAuthError(respWriter http.ResponseWriter, code int, errorCode, description string) {
+	resp := struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{Error: errorCode, ErrorDescription: description}
+	respJson, _ := json.Marshal(resp)
+	respWriter.Header().Set("Content-Type", "application/json")
+	respWriter.Header().Set("Cache-Control", "no-store")
+	respWriter.WriteHeader(code)
+	respWriter.Write(respJson)
+}
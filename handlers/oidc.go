@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"Chirpy/internal/oidc"
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	oidcLoginStateExpiry = 10 * time.Minute
+	// oidcStateCookie holds a hash of the state in the browser that started
+	// the login, so the callback can't be completed in another browser.
+	oidcStateCookie = "chirpy_oidc_state"
+)
+
+func (usersHandler *UsersHandler) HandlerOIDCLogin(respWriter http.ResponseWriter, req *http.Request) {
+	if usersHandler.OIDC == nil {
+		helpers.RespondWithError(respWriter, 404, "OIDC login is not configured.")
+		return
+	}
+	authURL, err := usersHandler.startOIDCLogin(respWriter, req, uuid.NullUUID{})
+	if err != nil {
+		helpers.RespondWithError(respWriter, 502, "Unable to reach identity provider.")
+		return
+	}
+	http.Redirect(respWriter, req, authURL, http.StatusFound)
+}
+
+// HandlerOIDCLink starts a login at the identity provider whose identity is
+// attached to the already authenticated user instead of logging in.
+func (usersHandler *UsersHandler) HandlerOIDCLink(respWriter http.ResponseWriter, req *http.Request) {
+	if usersHandler.OIDC == nil {
+		helpers.RespondWithError(respWriter, 404, "OIDC login is not configured.")
+		return
+	}
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	authURL, err := usersHandler.startOIDCLogin(respWriter, req, uuid.NullUUID{UUID: userId, Valid: true})
+	if err != nil {
+		helpers.RespondWithError(respWriter, 502, "Unable to reach identity provider.")
+		return
+	}
+	resp := struct {
+		RedirectTo string `json:"redirect_to"`
+	}{RedirectTo: authURL}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+// startOIDCLogin stores a new login state and returns the provider URL to send
+// the browser to. It also sets the state cookie on the response.
+func (usersHandler *UsersHandler) startOIDCLogin(respWriter http.ResponseWriter, req *http.Request, linkUserId uuid.NullUUID) (string, error) {
+	state, err := usersHandler.DB.CreateOIDCLoginState(req.Context(), database.CreateOIDCLoginStateParams{
+		State:        auth.MakeRefreshToken(),
+		Nonce:        auth.MakeRefreshToken(),
+		CodeVerifier: auth.MakeRefreshToken(),
+		LinkUserID:   linkUserId,
+		ExpiresAt:    time.Now().Add(oidcLoginStateExpiry),
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to create oidc login state: %v", err)
+		return "", err
+	}
+	authURL, err := usersHandler.OIDC.AuthCodeURL(req.Context(), state.State, state.Nonce, auth.MakePKCEChallenge(state.CodeVerifier))
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to build oidc auth url: %v", err)
+		return "", err
+	}
+	setOIDCStateCookie(respWriter, req, auth.HashToken(state.State), int(oidcLoginStateExpiry.Seconds()))
+	return authURL, nil
+}
+
+func setOIDCStateCookie(respWriter http.ResponseWriter, req *http.Request, value string, maxAge int) {
+	http.SetCookie(respWriter, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/api/auth/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// hasOIDCStateCookie reports whether the browser calling back is the one
+// that started the login for state.
+func hasOIDCStateCookie(req *http.Request, state string) bool {
+	cookie, err := req.Cookie(oidcStateCookie)
+	if err != nil || state == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(auth.HashToken(state))) == 1
+}
+
+func (usersHandler *UsersHandler) HandlerOIDCCallback(respWriter http.ResponseWriter, req *http.Request) {
+	if usersHandler.OIDC == nil {
+		helpers.RespondWithError(respWriter, 404, "OIDC login is not configured.")
+		return
+	}
+	query := req.URL.Query()
+	if query.Get("error") != "" {
+		helpers.RespondWithError(respWriter, 401, "Login at identity provider failed: "+query.Get("error"))
+		return
+	}
+	if !hasOIDCStateCookie(req, query.Get("state")) {
+		helpers.RespondWithError(respWriter, 400, "Invalid state. Finish the login in the browser that started it.")
+		return
+	}
+	setOIDCStateCookie(respWriter, req, "", -1)
+	state, err := usersHandler.DB.ConsumeOIDCLoginState(req.Context(), query.Get("state"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 400, "Invalid state.")
+			return
+		}
+		usersHandler.Logger.Printf("Error trying to get oidc login state: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if time.Now().After(state.ExpiresAt) {
+		helpers.RespondWithError(respWriter, 400, "Login expired. Please try again.")
+		return
+	}
+	claims, err := usersHandler.OIDC.Exchange(req.Context(), query.Get("code"), state.CodeVerifier, state.Nonce)
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to exchange oidc code: %v", err)
+		helpers.RespondWithError(respWriter, 401, "Unable to verify identity provider login.")
+		return
+	}
+	identity, err := usersHandler.DB.GetUserIdentity(req.Context(), database.GetUserIdentityParams{Issuer: claims.Issuer, Subject: claims.Subject})
+	if err != nil && err != sql.ErrNoRows {
+		usersHandler.Logger.Printf("Error trying to get user identity: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	identityExists := err == nil
+	if state.LinkUserID.Valid {
+		if identityExists && identity.UserID != state.LinkUserID.UUID {
+			helpers.RespondWithError(respWriter, 409, "This identity is already linked to another user.")
+			return
+		}
+		if !identityExists {
+			_, err = usersHandler.createUserIdentity(req, state.LinkUserID.UUID, claims)
+			if err != nil {
+				helpers.RespondWithError(respWriter, 500, "Internal server error.")
+				return
+			}
+		}
+		resp := struct {
+			Linked bool `json:"linked"`
+		}{Linked: true}
+		helpers.RespondWithJson(respWriter, 200, resp)
+		return
+	}
+	userId := identity.UserID
+	if !identityExists {
+		user, status, msg := usersHandler.findOrCreateOIDCUser(req, claims)
+		if status != 0 {
+			helpers.RespondWithError(respWriter, status, msg)
+			return
+		}
+		_, err = usersHandler.createUserIdentity(req, user.ID, claims)
+		if err != nil {
+			helpers.RespondWithError(respWriter, 500, "Internal server error.")
+			return
+		}
+		userId = user.ID
+	}
+	user, err := usersHandler.DB.GetUser(req.Context(), userId)
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to get user from db. UserId: %v Error : %v", userId, err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	totp, err := usersHandler.DB.GetUserTOTP(req.Context(), user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		usersHandler.Logger.Printf("Error trying to get totp settings for user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if err == nil && totp.Enabled {
+		usersHandler.respondWithTwoFactorChallenge(respWriter, req, user.ID)
+		return
+	}
+	usersHandler.respondWithLoginTokens(respWriter, req, user)
+}
+
+// findOrCreateOIDCUser creates a user for a first time external login. An
+// existing account is only matched by email when the provider verified it,
+// otherwise anyone could claim an account by registering its email elsewhere.
+func (usersHandler *UsersHandler) findOrCreateOIDCUser(req *http.Request, claims oidc.Claims) (database.User, int, string) {
+	if claims.Email == "" {
+		return database.User{}, 400, "Identity provider did not return an email."
+	}
+	existing, err := usersHandler.DB.GetUserByEmail(req.Context(), claims.Email)
+	if err == nil {
+		if !claims.EmailVerified {
+			return database.User{}, 409, "An account with this email already exists. Log in and link the identity instead."
+		}
+		return existing, 0, ""
+	}
+	if err != sql.ErrNoRows {
+		usersHandler.Logger.Printf("Error Trying to get user by Email: %v", err)
+		return database.User{}, 500, "Internal server error."
+	}
+	// External users have no usable password until they set one.
+	hashedPassword, err := auth.HashPassword(auth.MakeRefreshToken())
+	if err != nil {
+		usersHandler.Logger.Printf("Error Happened while trying to hash password: %v", err)
+		return database.User{}, 500, "Internal server error."
+	}
+	user, err := usersHandler.DB.CreateUser(req.Context(), database.CreateUserParams{Email: claims.Email, HashedPassword: hashedPassword})
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key value") {
+			return database.User{}, 409, "User already exists."
+		}
+		usersHandler.Logger.Printf("Error Happened while trying to create user: %v", err)
+		return database.User{}, 500, "Internal server error."
+	}
+	return user, 0, ""
+}
+
+func (usersHandler *UsersHandler) createUserIdentity(req *http.Request, userId uuid.UUID, claims oidc.Claims) (database.UserIdentity, error) {
+	identity, err := usersHandler.DB.CreateUserIdentity(req.Context(), database.CreateUserIdentityParams{
+		UserID:  userId,
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to link user identity: %v", err)
+	}
+	return identity, err
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"Chirpy/internal/auth"
+	"Chirpy/internal/oidc"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	cases := map[string]struct {
+		cookie   string
+		consumed bool
+	}{
+		"no cookie":          {"", false},
+		"another state":      {auth.HashToken("other-state"), false},
+		"the unhashed state": {"state", false},
+		"matching cookie":    {auth.HashToken("state"), true},
+	}
+	for name, c := range cases {
+		db := newFakeDB()
+		db.empty("ConsumeOIDCLoginState")
+		usersHandler := UsersHandler{db.apiConfig()}
+		usersHandler.OIDC = &oidc.Provider{}
+		req := httptest.NewRequest("GET", "/api/auth/oidc/callback?state=state&code=code", nil)
+		if c.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: c.cookie})
+		}
+		respWriter := httptest.NewRecorder()
+		usersHandler.HandlerOIDCCallback(respWriter, req)
+		if respWriter.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %v: %v", name, respWriter.Code, respWriter.Body)
+			t.FailNow()
+		}
+		if consumed := len(db.called("ConsumeOIDCLoginState")) == 1; consumed != c.consumed {
+			t.Errorf("%v: expected the state to be consumed=%v.", name, c.consumed)
+			t.FailNow()
+		}
+	}
+}
+
+func TestSetOIDCStateCookie(t *testing.T) {
+	respWriter := httptest.NewRecorder()
+	setOIDCStateCookie(respWriter, httptest.NewRequest("GET", "/api/auth/oidc/login", nil), auth.HashToken("state"), 600)
+	cookies := respWriter.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode || cookies[0].Value != auth.HashToken("state") {
+		t.Errorf("Unexpected state cookie %+v", cookies)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	pageDefaultLimit = 20
+	pageMaxLimit     = 100
+)
+
+// page is a keyset page over rows ordered newest first, ties broken by id.
+// The cursor is the timestamp and id of the last row on the previous page;
+// the first page has none and starts from the newest row.
+type page struct {
+	Limit    int
+	BeforeAt sql.NullTime
+	BeforeID uuid.NullUUID
+}
+
+func parseLimit(query url.Values) (int, error) {
+	rawLimit := query.Get("limit")
+	if rawLimit == "" {
+		return pageDefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil || limit < 1 || limit > pageMaxLimit {
+		return 0, fmt.Errorf("Invalid limit.")
+	}
+	return limit, nil
+}
+
+func parsePage(query url.Values) (page, error) {
+	limit, err := parseLimit(query)
+	if err != nil {
+		return page{}, err
+	}
+	result := page{Limit: limit}
+	if cursor := query.Get("cursor"); cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return page{}, fmt.Errorf("Invalid cursor.")
+		}
+		rawTime, rawId, found := strings.Cut(string(decoded), "|")
+		if !found {
+			return page{}, fmt.Errorf("Invalid cursor.")
+		}
+		before, err := time.Parse(time.RFC3339Nano, rawTime)
+		if err != nil {
+			return page{}, fmt.Errorf("Invalid cursor.")
+		}
+		beforeId, err := uuid.Parse(rawId)
+		if err != nil {
+			return page{}, fmt.Errorf("Invalid cursor.")
+		}
+		result.BeforeAt = sql.NullTime{Time: before, Valid: true}
+		result.BeforeID = uuid.NullUUID{UUID: beforeId, Valid: true}
+	}
+	return result, nil
+}
+
+// nextCursor returns the cursor for the page after one that returned count
+// rows, or nil when that was the last page.
+func (p page) nextCursor(count int, lastAt time.Time, lastId uuid.UUID) *string {
+	if count < p.Limit {
+		return nil
+	}
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(lastAt.Format(time.RFC3339Nano) + "|" + lastId.String()))
+	return &cursor
+}
+
+// rankPage is a page over a ranking, best first. The cursor is the rank of
+// the last row on the previous page.
+type rankPage struct {
+	Limit     int
+	AfterRank int32
+}
+
+func parseRankPage(query url.Values) (rankPage, error) {
+	limit, err := parseLimit(query)
+	if err != nil {
+		return rankPage{}, err
+	}
+	result := rankPage{Limit: limit}
+	if cursor := query.Get("cursor"); cursor != "" {
+		afterRank, err := strconv.ParseInt(cursor, 10, 32)
+		if err != nil || afterRank < 0 {
+			return rankPage{}, fmt.Errorf("Invalid cursor.")
+		}
+		result.AfterRank = int32(afterRank)
+	}
+	return result, nil
+}
+
+func (p rankPage) nextCursor(count int, lastRank int32) *string {
+	if count < p.Limit {
+		return nil
+	}
+	cursor := strconv.Itoa(int(lastRank))
+	return &cursor
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParsePage(t *testing.T) {
+	for _, rawQuery := range []string{"", "limit=1", "limit=100"} {
+		query, _ := url.ParseQuery(rawQuery)
+		result, err := parsePage(query)
+		if err != nil {
+			t.Errorf("parsePage(%q) = %v", rawQuery, err)
+			t.FailNow()
+		}
+		if result.BeforeAt.Valid || result.BeforeID.Valid {
+			t.Errorf("Expected the first page of %q to have no bound, got %+v.", rawQuery, result)
+			t.FailNow()
+		}
+	}
+	cursor := base64.RawURLEncoding.EncodeToString([]byte("2024-10-01T12:00:00Z"))
+	for _, invalid := range []string{"limit=0", "limit=101", "limit=ten", "cursor=yesterday", "cursor=" + cursor} {
+		query, _ := url.ParseQuery(invalid)
+		if _, err := parsePage(query); err == nil {
+			t.Errorf("Expected %q to be rejected.", invalid)
+			t.FailNow()
+		}
+	}
+}
+
+func TestPageNextCursor(t *testing.T) {
+	last := time.Date(2024, 10, 1, 12, 0, 0, 123000, time.FixedZone("CEST", 2*60*60))
+	lastId := uuid.New()
+	p := page{Limit: 2}
+	if cursor := p.nextCursor(1, last, lastId); cursor != nil {
+		t.Errorf("Expected no cursor after a short page, got %v.", *cursor)
+		t.FailNow()
+	}
+	cursor := p.nextCursor(2, last, lastId)
+	if cursor == nil {
+		t.Errorf("Expected a cursor after a full page.")
+		t.FailNow()
+	}
+	if url.QueryEscape(*cursor) != *cursor {
+		t.Errorf("Expected cursor %v to be safe in a query string.", *cursor)
+		t.FailNow()
+	}
+	query := url.Values{"cursor": {*cursor}}
+	next, err := parsePage(query)
+	if err != nil || !next.BeforeAt.Valid || !next.BeforeAt.Time.Equal(last) || next.BeforeID.UUID != lastId {
+		t.Errorf("Cursor %v did not round trip: %+v, %v", *cursor, next, err)
+		t.FailNow()
+	}
+}
+
+func TestParseRankPage(t *testing.T) {
+	query, _ := url.ParseQuery("limit=5&cursor=40")
+	result, err := parseRankPage(query)
+	if err != nil || result.Limit != 5 || result.AfterRank != 40 {
+		t.Errorf("Unexpected rank page %+v, %v", result, err)
+		t.FailNow()
+	}
+	for _, invalid := range []string{"cursor=-1", "cursor=abc", "limit=0"} {
+		query, _ := url.ParseQuery(invalid)
+		if _, err := parseRankPage(query); err == nil {
+			t.Errorf("Expected %q to be rejected.", invalid)
+			t.FailNow()
+		}
+	}
+	if cursor := (rankPage{Limit: 5}).nextCursor(5, 45); cursor == nil || *cursor != "45" {
+		t.Errorf("Unexpected next cursor %v.", cursor)
+		t.FailNow()
+	}
+	if cursor := (rankPage{Limit: 5}).nextCursor(4, 44); cursor != nil {
+		t.Errorf("Expected no cursor after a short page, got %v.", *cursor)
+		t.FailNow()
+	}
+}
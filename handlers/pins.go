@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"Chirpy/internal/entitlements"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+var errTooManyPins = errors.New("too many pinned chirps")
+
+// pinnedFirst moves the author's pinned chirps to the front, most recently
+// pinned first, and marks them. The rest keep their order.
+func pinnedFirst(ctx context.Context, db *database.Queries, authorId uuid.UUID, chirps []chirpResponse) ([]chirpResponse, error) {
+	pinnedIds, err := db.GetPinnedChirpIDs(ctx, authorId)
+	if err != nil || len(pinnedIds) == 0 {
+		return chirps, err
+	}
+	byId := map[uuid.UUID]chirpResponse{}
+	for _, chirp := range chirps {
+		byId[chirp.ID] = chirp
+	}
+	sorted := make([]chirpResponse, 0, len(chirps))
+	pinned := map[uuid.UUID]bool{}
+	for _, chirpId := range pinnedIds {
+		chirp, ok := byId[chirpId]
+		if !ok {
+			continue
+		}
+		chirp.Pinned = true
+		sorted = append(sorted, chirp)
+		pinned[chirpId] = true
+	}
+	for _, chirp := range chirps {
+		if !pinned[chirp.ID] {
+			sorted = append(sorted, chirp)
+		}
+	}
+	return sorted, nil
+}
+
+// getOwnChirpToPin loads a chirp from the path and checks it belongs to the
+// caller. On failure the response is written.
+func (chirpHanlder *ChirpHandler) getOwnChirpToPin(respWriter http.ResponseWriter, req *http.Request, userId uuid.UUID) (database.Chirp, error) {
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid chirpID.")
+		return database.Chirp{}, err
+	}
+	chirp, err := chirpHanlder.DB.GetOneChirp(req.Context(), chirpId)
+	if err != nil && err != sql.ErrNoRows {
+		chirpHanlder.Logger.Printf("Error getting chirp from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return database.Chirp{}, err
+	}
+	if err == sql.ErrNoRows {
+		helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
+		return database.Chirp{}, err
+	}
+	if chirp.UserID != userId {
+		helpers.RespondWithError(respWriter, 403, "You can only pin your own chirps.")
+		return database.Chirp{}, errors.New("not the author")
+	}
+	return chirp, nil
+}
+
+// HandlerPinChirp pins one of the caller's chirps to the top of their chirps.
+// Pinning a chirp twice is a no-op. Users who lose Chirpy Red keep their pins
+// but can't pin more until they are under the free limit.
+func (chirpHanlder *ChirpHandler) HandlerPinChirp(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	chirp, err := chirpHanlder.getOwnChirpToPin(respWriter, req, userId)
+	if err != nil {
+		return
+	}
+	if !isChirpVisible(chirp) {
+		helpers.RespondWithError(respWriter, 400, "Only published chirps can be pinned.")
+		return
+	}
+	user, err := chirpHanlder.DB.GetUser(req.Context(), userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 401, "User not found.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error getting user from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	maxPins := entitlements.ForUser(user).MaxPinnedChirps
+	err = withTx(req.Context(), chirpHanlder.ApiConfig, func(qtx *database.Queries) error {
+		pinned, err := qtx.PinChirp(req.Context(), database.PinChirpParams{ChirpID: chirp.ID, UserID: userId})
+		if err != nil || pinned == 0 {
+			return err
+		}
+		// Counted after inserting so concurrent pins can't both slip under the limit.
+		count, err := qtx.CountPinnedChirps(req.Context(), userId)
+		if err != nil {
+			return err
+		}
+		if count > int64(maxPins) {
+			return errTooManyPins
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errTooManyPins) {
+			helpers.RespondWithError(respWriter, 409, fmt.Sprintf("You can pin at most %v chirps.", maxPins))
+			return
+		}
+		chirpHanlder.Logger.Printf("Error trying to pin chirp: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerUnpinChirp removes one of the caller's pins. Chirps a moderator hid
+// after they were pinned can be unpinned too, so they don't keep taking up a
+// pin.
+func (chirpHanlder *ChirpHandler) HandlerUnpinChirp(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	chirp, err := chirpHanlder.getOwnChirpToPin(respWriter, req, userId)
+	if err != nil {
+		return
+	}
+	unpinned, err := chirpHanlder.DB.UnpinChirp(req.Context(), database.UnpinChirpParams{ChirpID: chirp.ID, UserID: userId})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to unpin chirp: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if unpinned == 0 {
+		helpers.RespondWithError(respWriter, 404, "This chirp isn't pinned.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPinnedFirst(t *testing.T) {
+	db := newFakeDB()
+	apiCfg := db.apiConfig()
+	chirps := []chirpResponse{{ID: uuid.New()}, {ID: uuid.New()}, {ID: uuid.New()}}
+	// The second pin is on a chirp that isn't part of this page.
+	db.set("GetPinnedChirpIDs", fakeIds(chirps[2].ID, uuid.New(), chirps[1].ID))
+
+	sorted, err := pinnedFirst(context.Background(), apiCfg.DB, uuid.New(), chirps)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		t.FailNow()
+	}
+	expected := []struct {
+		id     uuid.UUID
+		pinned bool
+	}{{chirps[2].ID, true}, {chirps[1].ID, true}, {chirps[0].ID, false}}
+	if len(sorted) != len(expected) {
+		t.Errorf("Expected %v chirps, got %v.", len(expected), len(sorted))
+		t.FailNow()
+	}
+	for idx, chirp := range sorted {
+		if chirp.ID != expected[idx].id || chirp.Pinned != expected[idx].pinned {
+			t.Errorf("Unexpected chirp %v at %v: %+v", chirp.ID, idx, chirp)
+			t.FailNow()
+		}
+	}
+}
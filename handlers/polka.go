@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"Chirpy/internal/outbox"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	polkaSignatureTolerance = 5 * time.Minute
+	polkaMaxBodySize        = 1 << 20
+)
+
+// HandlerPolkaWebhook applies Chirpy Red changes sent by Polka. Deliveries must
+// carry a valid X-Polka-Signature, and each event ID is only applied once so
+// retried deliveries are acknowledged without being processed again.
+func (usersHandler *UsersHandler) HandlerPolkaWebhook(respWriter http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(req.Body, polkaMaxBodySize))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	err = auth.VerifyWebhookSignature(usersHandler.PolkaKey, req.Header.Get("X-Polka-Signature"), body, time.Now(), polkaSignatureTolerance)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 401, "Invalid signature.")
+		return
+	}
+	reqBody := struct {
+		Id    string `json:"id"`
+		Event string `json:"event"`
+		Data  struct {
+			UserId string `json:"user_id"`
+			Plan   string `json:"plan"`
+		} `json:"data"`
+	}{}
+	err = json.Unmarshal(body, &reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	if reqBody.Id == "" || reqBody.Event == "" || reqBody.Data.UserId == "" {
+		helpers.RespondWithError(respWriter, 400, "Invalid request body.")
+		return
+	}
+	if reqBody.Event != "user.upgraded" && reqBody.Event != "user.downgraded" {
+		usersHandler.logPolkaDelivery(req, reqBody.Id, reqBody.Event, body, "ignored")
+		respWriter.WriteHeader(http.StatusNoContent)
+		return
+	}
+	userId, err := uuid.Parse(reqBody.Data.UserId)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid user_id.")
+		return
+	}
+	status, err := usersHandler.applyPolkaEvent(req, reqBody.Id, reqBody.Event, userId, reqBody.Data.Plan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			usersHandler.logPolkaDelivery(req, reqBody.Id, reqBody.Event, body, "user_not_found")
+			helpers.RespondWithError(respWriter, 404, "Invalid request. No user found for given user_id.")
+			return
+		}
+		usersHandler.Logger.Printf("Error applying polka event %v: %v", reqBody.Id, err)
+		usersHandler.logPolkaDelivery(req, reqBody.Id, reqBody.Event, body, "failed")
+		helpers.RespondWithError(respWriter, 500, "Internal server error. Please try again.")
+		return
+	}
+	usersHandler.logPolkaDelivery(req, reqBody.Id, reqBody.Event, body, status)
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+// applyPolkaEvent records the event ID and changes the subscription in one
+// transaction, so a failed update can be retried and a successful one is never
+// applied twice.
+func (usersHandler *UsersHandler) applyPolkaEvent(req *http.Request, eventId, event string, userId uuid.UUID, plan string) (string, error) {
+	tx, err := usersHandler.SQLDB.BeginTx(req.Context(), nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	qtx := usersHandler.DB.WithTx(tx)
+	inserted, err := qtx.CreatePolkaEvent(req.Context(), database.CreatePolkaEventParams{EventID: eventId, Event: event, UserID: userId})
+	if err != nil {
+		return "", err
+	}
+	if inserted == 0 {
+		return "duplicate", nil
+	}
+	_, err = qtx.GetUser(req.Context(), userId)
+	if err != nil {
+		return "", err
+	}
+	if event == "user.upgraded" {
+		var user database.User
+		user, err = activateSubscription(req.Context(), qtx, userId, plan)
+		if err == nil {
+			err = outbox.Record(req.Context(), qtx, outbox.EventUserUpgraded, userId, struct {
+				Id          uuid.UUID `json:"id"`
+				Email       string    `json:"email"`
+				IsChirpyRed bool      `json:"is_chirpy_red"`
+				Plan        string    `json:"plan"`
+			}{Id: user.ID, Email: user.Email, IsChirpyRed: user.IsChirpyRed, Plan: plan})
+		}
+	} else {
+		_, err = cancelSubscription(req.Context(), qtx, userId)
+		if err == nil {
+			err = outbox.Record(req.Context(), qtx, outbox.EventUserDowngraded, userId, struct {
+				Id uuid.UUID `json:"id"`
+			}{Id: userId})
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return "", err
+	}
+	usersHandler.Outbox.Notify()
+	return "processed", nil
+}
+
+func (usersHandler *UsersHandler) logPolkaDelivery(req *http.Request, eventId, event string, payload []byte, status string) {
+	err := usersHandler.DB.CreatePolkaDelivery(req.Context(), database.CreatePolkaDeliveryParams{
+		EventID: eventId,
+		Event:   event,
+		Payload: payload,
+		Status:  status,
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to log polka delivery %v: %v", eventId, err)
+	}
+}
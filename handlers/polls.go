@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"Chirpy/internal/polls"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type pollRequest struct {
+	Options         []string `json:"options"`
+	DurationMinutes int      `json:"duration_minutes"`
+}
+
+type pollOptionResponse struct {
+	Id    uuid.UUID `json:"id"`
+	Text  string    `json:"text"`
+	Votes *int64    `json:"votes"`
+}
+
+// pollResponse leaves votes and total_votes null while the viewer may not see
+// the results yet.
+type pollResponse struct {
+	Options       []pollOptionResponse `json:"options"`
+	ClosesAt      time.Time            `json:"closes_at"`
+	Closed        bool                 `json:"closed"`
+	TotalVotes    *int64               `json:"total_votes"`
+	VotedOptionId *uuid.UUID           `json:"voted_option_id"`
+}
+
+func createPoll(ctx context.Context, qtx *database.Queries, chirpId uuid.UUID, options []string, closesAt time.Time) error {
+	err := qtx.CreatePoll(ctx, database.CreatePollParams{ChirpID: chirpId, ClosesAt: closesAt})
+	if err != nil {
+		return err
+	}
+	for position, option := range options {
+		err = qtx.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ChirpID:  chirpId,
+			Position: int32(position),
+			Text:     option,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPolls fills in the polls of the given chirps with live tallies as the
+// viewer may see them. Without a viewer only closed polls show results.
+func loadPolls(ctx context.Context, db *database.Queries, chirps []chirpResponse, viewerId uuid.NullUUID) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+	chirpIds := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIds = append(chirpIds, chirp.ID)
+	}
+	options, err := db.GetPollOptionsForChirps(ctx, chirpIds)
+	if err != nil || len(options) == 0 {
+		return err
+	}
+	votedFor := map[uuid.UUID]uuid.UUID{}
+	if viewerId.Valid {
+		votes, err := db.GetPollVotesByUser(ctx, database.GetPollVotesByUserParams{UserID: viewerId.UUID, ChirpIds: chirpIds})
+		if err != nil {
+			return err
+		}
+		for _, vote := range votes {
+			votedFor[vote.ChirpID] = vote.OptionID
+		}
+	}
+	byChirp := map[uuid.UUID][]database.GetPollOptionsForChirpsRow{}
+	for _, option := range options {
+		byChirp[option.ChirpID] = append(byChirp[option.ChirpID], option)
+	}
+	now := time.Now()
+	for i := range chirps {
+		chirpOptions := byChirp[chirps[i].ID]
+		if len(chirpOptions) == 0 {
+			continue
+		}
+		closesAt := chirpOptions[0].ClosesAt
+		poll := &pollResponse{
+			Options:  make([]pollOptionResponse, 0, len(chirpOptions)),
+			ClosesAt: closesAt,
+			Closed:   polls.IsClosed(closesAt, now),
+		}
+		optionId, hasVoted := votedFor[chirps[i].ID]
+		if hasVoted {
+			poll.VotedOptionId = &optionId
+		}
+		isAuthor := viewerId.Valid && viewerId.UUID == chirps[i].UserID
+		showResults := polls.CanSeeResults(hasVoted, isAuthor, closesAt, now)
+		total := int64(0)
+		for _, option := range chirpOptions {
+			optionResp := pollOptionResponse{Id: option.ID, Text: option.Text}
+			if showResults {
+				votes := option.Votes
+				optionResp.Votes = &votes
+			}
+			total += option.Votes
+			poll.Options = append(poll.Options, optionResp)
+		}
+		if showResults {
+			poll.TotalVotes = &total
+		}
+		chirps[i].Poll = poll
+	}
+	return nil
+}
+
+// HandlerVotePoll records the caller's vote. Votes can't be changed, and the
+// response shows the results now that the caller has voted.
+func (chirpHanlder *ChirpHandler) HandlerVotePoll(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid chirpID.")
+		return
+	}
+	reqBody := struct {
+		OptionId uuid.UUID `json:"option_id"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	chirp, err := chirpHanlder.DB.GetOneChirp(req.Context(), chirpId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error getting chirp from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if !isChirpVisible(chirp) {
+		helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
+		return
+	}
+	poll, err := chirpHanlder.DB.GetPoll(req.Context(), chirpId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "This chirp has no poll.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error getting poll from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if polls.IsClosed(poll.ClosesAt, time.Now()) {
+		helpers.RespondWithError(respWriter, 403, "This poll is closed.")
+		return
+	}
+	option, err := chirpHanlder.DB.GetPollOption(req.Context(), reqBody.OptionId)
+	if err != nil && err != sql.ErrNoRows {
+		chirpHanlder.Logger.Printf("Error getting poll option from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if err == sql.ErrNoRows || option.ChirpID != chirpId {
+		helpers.RespondWithError(respWriter, 400, "Invalid option_id for this poll.")
+		return
+	}
+	voted, err := chirpHanlder.DB.CreatePollVote(req.Context(), database.CreatePollVoteParams{
+		ChirpID:  chirpId,
+		UserID:   userId,
+		OptionID: option.ID,
+	})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to vote in poll: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if voted == 0 {
+		helpers.RespondWithError(respWriter, 409, "You already voted in this poll.")
+		return
+	}
+	resp := []chirpResponse{newChirpResponse(chirp)}
+	err = loadPolls(req.Context(), chirpHanlder.DB, resp, uuid.NullUUID{UUID: userId, Valid: true})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting poll from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 200, resp[0].Poll)
+}
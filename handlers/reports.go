@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"Chirpy/internal/moderation"
+	"Chirpy/internal/validation"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type reportResponse struct {
+	Id         uuid.UUID `json:"id"`
+	ChirpId    uuid.UUID `json:"chirp_id"`
+	ReporterId uuid.UUID `json:"reporter_id"`
+	Reason     string    `json:"reason"`
+	Details    *string   `json:"details"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newReportResponse(report database.Report) reportResponse {
+	resp := reportResponse{
+		Id:         report.ID,
+		ChirpId:    report.ChirpID,
+		ReporterId: report.ReporterID,
+		Reason:     report.Reason,
+		Status:     report.Status,
+		CreatedAt:  report.CreatedAt,
+	}
+	if report.Details.Valid {
+		resp.Details = &report.Details.String
+	}
+	return resp
+}
+
+// HandlerReportChirp flags a chirp for the moderation queue. Reporting the
+// same chirp again replaces your earlier report.
+func (chirpHanlder *ChirpHandler) HandlerReportChirp(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid chirpID.")
+		return
+	}
+	reqBody := struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	err = moderation.ValidateReason(reqBody.Reason)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	details := validation.Clean(reqBody.Details)
+	if validation.Length(details) > moderation.MaxDetailsLength {
+		helpers.RespondWithError(respWriter, 400, "Details are too long.")
+		return
+	}
+	chirp, err := chirpHanlder.DB.GetOneChirp(req.Context(), chirpId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error getting chirp from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if !isChirpVisible(chirp) {
+		helpers.RespondWithError(respWriter, 404, "No chirp found for the given chirpID.")
+		return
+	}
+	if chirp.UserID == userId {
+		helpers.RespondWithError(respWriter, 400, "You cannot report your own chirp.")
+		return
+	}
+	report, err := chirpHanlder.DB.CreateReport(req.Context(), database.CreateReportParams{
+		ChirpID:    chirpId,
+		ReporterID: userId,
+		Reason:     reqBody.Reason,
+		Details:    sql.NullString{String: details, Valid: details != ""},
+	})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error trying to create report: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 201, newReportResponse(report))
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// HandlerGetScheduledChirps lists the caller's chirps that are waiting to be
+// published, soonest first.
+func (chirpHanlder *ChirpHandler) HandlerGetScheduledChirps(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsRead)
+	if err != nil {
+		return
+	}
+	chirps, err := chirpHanlder.DB.GetScheduledChirps(req.Context(), userId)
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting scheduled chirps from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := newChirpResponses(chirps)
+	err = loadChirpDetails(req.Context(), chirpHanlder.DB, resp, uuid.NullUUID{UUID: userId, Valid: true})
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting chirp details from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+// HandlerCancelScheduledChirp deletes one of the caller's chirps before it is
+// published. Other users' scheduled chirps don't exist as far as they know.
+func (chirpHanlder *ChirpHandler) HandlerCancelScheduledChirp(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsWrite)
+	if err != nil {
+		return
+	}
+	chirpId, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid chirpID.")
+		return
+	}
+	chirp, err := chirpHanlder.DB.GetOneChirp(req.Context(), chirpId)
+	if err != nil && err != sql.ErrNoRows {
+		chirpHanlder.Logger.Printf("Error getting chirp from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if err == sql.ErrNoRows || chirp.UserID != userId {
+		helpers.RespondWithError(respWriter, 404, "No scheduled chirp found for the given chirpID.")
+		return
+	}
+	_, err = chirpHanlder.DB.DeleteScheduledChirp(req.Context(), database.DeleteScheduledChirpParams{ID: chirpId, UserID: userId})
+	if err != nil {
+		// The publish job may have got to it since it was read.
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 409, "This chirp has already been published.")
+			return
+		}
+		chirpHanlder.Logger.Printf("Error trying to cancel scheduled chirp: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
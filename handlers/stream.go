@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/stream"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const streamHeartbeatInterval = 15 * time.Second
+
+// HandlerStream pushes chirp events as Server-Sent Events. Clients can narrow
+// the stream with ?author_id= or ?following=true, and resume after a
+// reconnect with the Last-Event-ID header.
+func (chirpHanlder *ChirpHandler) HandlerStream(respWriter http.ResponseWriter, req *http.Request) {
+	filter, ok := chirpHanlder.streamFilter(respWriter, req)
+	if !ok {
+		return
+	}
+	lastEventId := req.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = req.URL.Query().Get("last_event_id")
+	}
+	var resumeFrom uint64
+	if lastEventId != "" {
+		parsed, err := strconv.ParseUint(lastEventId, 10, 64)
+		if err != nil {
+			helpers.RespondWithError(respWriter, 400, "Invalid Last-Event-ID.")
+			return
+		}
+		resumeFrom = parsed
+	}
+	controller := http.NewResponseController(respWriter)
+	respWriter.Header().Set("Content-Type", "text/event-stream")
+	respWriter.Header().Set("Cache-Control", "no-cache")
+	respWriter.Header().Set("Connection", "keep-alive")
+	respWriter.Header().Set("X-Accel-Buffering", "no")
+	respWriter.WriteHeader(200)
+
+	subscription, backlog := chirpHanlder.Stream.Subscribe(resumeFrom, filter)
+	defer chirpHanlder.Stream.Unsubscribe(subscription)
+	fmt.Fprint(respWriter, "retry: 3000\n\n")
+	for _, event := range backlog {
+		writeStreamEvent(respWriter, event)
+	}
+	if controller.Flush() != nil {
+		return
+	}
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			writeStreamEvent(respWriter, event)
+		case <-heartbeat.C:
+			fmt.Fprint(respWriter, ": ping\n\n")
+		}
+		if controller.Flush() != nil {
+			return
+		}
+	}
+}
+
+func writeStreamEvent(respWriter http.ResponseWriter, event stream.Event) {
+	fmt.Fprintf(respWriter, "id: %v\nevent: %v\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
+
+// streamFilter builds the subscription filter from the query string. The
+// followed authors, minus muted ones, are read once when the stream starts.
+func (chirpHanlder *ChirpHandler) streamFilter(respWriter http.ResponseWriter, req *http.Request) (stream.Filter, bool) {
+	if queryAuthorId := req.URL.Query().Get("author_id"); queryAuthorId != "" {
+		authorId, err := uuid.Parse(queryAuthorId)
+		if err != nil {
+			helpers.RespondWithError(respWriter, 400, "Invalid author_id")
+			return nil, false
+		}
+		return func(event stream.Event) bool { return event.IsChirpEvent() && event.AuthorID == authorId }, true
+	}
+	if req.URL.Query().Get("following") != "true" {
+		return stream.Event.IsChirpEvent, true
+	}
+	userId, err := authenticateUser(chirpHanlder.ApiConfig, respWriter, req, auth.ScopeChirpsRead)
+	if err != nil {
+		return nil, false
+	}
+	followeeIds, err := chirpHanlder.DB.GetUnmutedFolloweeIDs(req.Context(), userId)
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error getting followed users from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return nil, false
+	}
+	following := map[uuid.UUID]bool{}
+	for _, followeeId := range followeeIds {
+		following[followeeId] = true
+	}
+	return func(event stream.Event) bool { return event.IsChirpEvent() && following[event.AuthorID] }, true
+}
+
+// publishChirpEvent tells live streams about a committed chirp change.
+func (chirpHanlder *ChirpHandler) publishChirpEvent(eventType string, chirp chirpResponse) {
+	_, err := chirpHanlder.Stream.Publish(stream.Event{
+		Type:     eventType,
+		AuthorID: chirp.UserID,
+		Hashtags: stream.Hashtags(chirp.Body),
+	}, chirp)
+	if err != nil {
+		chirpHanlder.Logger.Printf("Error publishing %v stream event: %v", eventType, err)
+	}
+}
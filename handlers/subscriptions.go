@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/database"
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	subscriptionPlanMonthly = "monthly"
+	subscriptionPlanYearly  = "yearly"
+)
+
+// nextSubscriptionExpiry extends an active subscription from its current expiry
+// so renewing early doesn't lose paid time, and starts a new period otherwise.
+func nextSubscriptionExpiry(plan string, current database.Subscription, hasCurrent bool, now time.Time) time.Time {
+	start := now
+	if hasCurrent && current.Status == "active" && current.ExpiresAt.After(now) {
+		start = current.ExpiresAt
+	}
+	if plan == subscriptionPlanYearly {
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// activateSubscription starts or renews the user's subscription and derives
+// is_chirpy_red from it. qtx should be bound to the caller's transaction.
+func activateSubscription(ctx context.Context, qtx *database.Queries, userId uuid.UUID, plan string) (database.User, error) {
+	if plan != subscriptionPlanYearly {
+		plan = subscriptionPlanMonthly
+	}
+	current, err := qtx.GetSubscriptionForUser(ctx, userId)
+	if err != nil && err != sql.ErrNoRows {
+		return database.User{}, err
+	}
+	_, err = qtx.UpsertSubscription(ctx, database.UpsertSubscriptionParams{
+		UserID:    userId,
+		Plan:      plan,
+		ExpiresAt: nextSubscriptionExpiry(plan, current, err == nil, time.Now()),
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	return qtx.SyncUserChirpyRed(ctx, userId)
+}
+
+func cancelSubscription(ctx context.Context, qtx *database.Queries, userId uuid.UUID) (database.User, error) {
+	err := qtx.CancelSubscription(ctx, userId)
+	if err != nil {
+		return database.User{}, err
+	}
+	return qtx.SyncUserChirpyRed(ctx, userId)
+}
+
+func (usersHandler *UsersHandler) HandlerGetSubscription(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	subscription, err := usersHandler.DB.GetSubscriptionForUser(req.Context(), userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No subscription found.")
+			return
+		}
+		usersHandler.Logger.Printf("Error trying to get subscription: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		Plan        string     `json:"plan"`
+		Status      string     `json:"status"`
+		StartedAt   time.Time  `json:"started_at"`
+		RenewedAt   *time.Time `json:"renewed_at"`
+		ExpiresAt   time.Time  `json:"expires_at"`
+		IsChirpyRed bool       `json:"is_chirpy_red"`
+	}{
+		Plan:        subscription.Plan,
+		Status:      subscription.Status,
+		StartedAt:   subscription.StartedAt,
+		ExpiresAt:   subscription.ExpiresAt,
+		IsChirpyRed: subscription.Status == "active" && subscription.ExpiresAt.After(time.Now()),
+	}
+	if subscription.RenewedAt.Valid {
+		resp.RenewedAt = &subscription.RenewedAt.Time
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/config"
+	"Chirpy/internal/database"
+	"Chirpy/internal/moderation"
+	"Chirpy/internal/validation"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type suspensionResponse struct {
+	UserId         uuid.UUID  `json:"user_id"`
+	SuspendedAt    time.Time  `json:"suspended_at"`
+	SuspendedUntil *time.Time `json:"suspended_until"`
+	Reason         *string    `json:"reason"`
+}
+
+func isWriteRequest(req *http.Request) bool {
+	return req.Method != http.MethodGet && req.Method != http.MethodHead && req.Method != http.MethodOptions
+}
+
+// rejectSuspendedUser responds with 403 if the user is currently suspended.
+// Users that no longer exist are left for the handler to report.
+func rejectSuspendedUser(apiCfg *config.ApiConfig, respWriter http.ResponseWriter, req *http.Request, userId uuid.UUID) error {
+	user, err := apiCfg.DB.GetUser(req.Context(), userId)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		apiCfg.Logger.Printf("Error getting user from db: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return err
+	}
+	if moderation.IsSuspended(user.SuspendedAt, user.SuspendedUntil, time.Now()) {
+		helpers.RespondWithError(respWriter, 403, moderation.SuspensionMessage(user.SuspendedUntil, user.SuspensionReason))
+		return fmt.Errorf("user %v is suspended", userId)
+	}
+	return nil
+}
+
+// MiddlewareRejectSuspended stops suspended users from changing anything with
+// a JWT. Reads still work so they can see their account. API keys and OAuth
+// access tokens are checked when they are authenticated.
+func MiddlewareRejectSuspended(apiCfg *config.ApiConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(respWriter http.ResponseWriter, req *http.Request) {
+		if !isWriteRequest(req) {
+			next.ServeHTTP(respWriter, req)
+			return
+		}
+		token, err := auth.GetBearerToken(req.Header)
+		if err != nil {
+			next.ServeHTTP(respWriter, req)
+			return
+		}
+		userId, err := auth.ValidateJWT(token, apiCfg.JWTSecret)
+		if err != nil {
+			// Not a JWT: refresh tokens, access tokens or garbage, which the
+			// handler deals with.
+			next.ServeHTTP(respWriter, req)
+			return
+		}
+		if rejectSuspendedUser(apiCfg, respWriter, req, userId) != nil {
+			return
+		}
+		next.ServeHTTP(respWriter, req)
+	})
+}
+
+// suspendUser suspends the user until the given time, or permanently if until
+// is nil, and revokes their refresh tokens so they are logged out everywhere
+// once their current JWT expires.
+func suspendUser(ctx context.Context, qtx *database.Queries, userId uuid.UUID, until *time.Time, reason string) (database.User, error) {
+	suspendedUntil := sql.NullTime{}
+	if until != nil {
+		suspendedUntil = sql.NullTime{Time: *until, Valid: true}
+	}
+	user, err := qtx.SuspendUser(ctx, database.SuspendUserParams{
+		ID:               userId,
+		SuspendedUntil:   suspendedUntil,
+		SuspensionReason: sql.NullString{String: reason, Valid: reason != ""},
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	_, err = qtx.RevokeUserRefreshTokens(ctx, userId)
+	return user, err
+}
+
+func (moderationHandler *ModerationHandler) HandlerSuspendUser(respWriter http.ResponseWriter, req *http.Request) {
+	moderatorId, err := authenticateModerator(moderationHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	userId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid userID.")
+		return
+	}
+	if moderatorId.Valid && moderatorId.UUID == userId {
+		helpers.RespondWithError(respWriter, 400, "You cannot suspend yourself.")
+		return
+	}
+	reqBody := struct {
+		Reason        string `json:"reason"`
+		DurationHours *int   `json:"duration_hours"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	until, err := moderation.SuspensionEnd(reqBody.DurationHours, time.Now())
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	reason := validation.Clean(reqBody.Reason)
+	var user database.User
+	err = withTx(req.Context(), moderationHandler.ApiConfig, func(qtx *database.Queries) error {
+		user, err = suspendUser(req.Context(), qtx, userId, until, reason)
+		if err != nil {
+			return err
+		}
+		_, err = qtx.CreateModerationAction(req.Context(), database.CreateModerationActionParams{
+			ModeratorID:  moderatorId,
+			Action:       moderation.ActionSuspend,
+			TargetUserID: uuid.NullUUID{UUID: userId, Valid: true},
+			Note:         sql.NullString{String: reason, Valid: reason != ""},
+		})
+		return err
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No user found for the given userID.")
+			return
+		}
+		moderationHandler.Logger.Printf("Error trying to suspend user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := suspensionResponse{UserId: user.ID, SuspendedAt: user.SuspendedAt.Time}
+	if user.SuspendedUntil.Valid {
+		resp.SuspendedUntil = &user.SuspendedUntil.Time
+	}
+	if user.SuspensionReason.Valid {
+		resp.Reason = &user.SuspensionReason.String
+	}
+	helpers.RespondWithJson(respWriter, 201, resp)
+}
+
+// HandlerUnsuspendUser lifts a suspension early. Revoked refresh tokens stay
+// revoked, so the user has to log in again.
+func (moderationHandler *ModerationHandler) HandlerUnsuspendUser(respWriter http.ResponseWriter, req *http.Request) {
+	moderatorId, err := authenticateModerator(moderationHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	userId, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid userID.")
+		return
+	}
+	var unsuspended int64
+	err = withTx(req.Context(), moderationHandler.ApiConfig, func(qtx *database.Queries) error {
+		unsuspended, err = qtx.UnsuspendUser(req.Context(), userId)
+		if err != nil || unsuspended == 0 {
+			return err
+		}
+		_, err = qtx.CreateModerationAction(req.Context(), database.CreateModerationActionParams{
+			ModeratorID:  moderatorId,
+			Action:       moderation.ActionUnsuspend,
+			TargetUserID: uuid.NullUUID{UUID: userId, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		moderationHandler.Logger.Printf("Error trying to unsuspend user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if unsuspended == 0 {
+		helpers.RespondWithError(respWriter, 404, "This user is not suspended.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"Chirpy/internal/config"
+	"Chirpy/internal/database"
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// withTx runs fn with queries bound to a new transaction and commits if fn
+// succeeds. Outbox events recorded inside fn are dispatched after the commit.
+func withTx(ctx context.Context, apiCfg *config.ApiConfig, fn func(qtx *database.Queries) error) error {
+	tx, err := apiCfg.SQLDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = fn(apiCfg.DB.WithTx(tx))
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	apiCfg.Outbox.Notify()
+	return nil
+}
+
+// isUniqueViolation reports whether err is Postgres rejecting a write that
+// breaks a unique index, which a concurrent request can cause even after the
+// query itself checked for duplicates.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/database"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	twoFactorChallengeExpiry      = 5 * time.Minute
+	twoFactorChallengeMaxAttempts = 5
+	recoveryCodeCount             = 10
+)
+
+func (usersHandler *UsersHandler) HandlerEnrollTwoFactor(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	user, err := usersHandler.DB.GetUser(req.Context(), userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 401, "Invalid token. No user associated with this token.")
+			return
+		}
+		usersHandler.Logger.Printf("Error trying to get user from db. UserId: %v Error : %v", userId, err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	existing, err := usersHandler.DB.GetUserTOTP(req.Context(), user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		usersHandler.Logger.Printf("Error trying to get totp settings for user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if err == nil && existing.Enabled {
+		helpers.RespondWithError(respWriter, 409, "Two factor authentication is already enabled.")
+		return
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to generate totp secret: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	_, err = usersHandler.DB.UpsertUserTOTP(req.Context(), database.UpsertUserTOTPParams{UserID: user.ID, Secret: secret})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to save totp secret: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauth_uri"`
+	}{
+		Secret:     secret,
+		OtpauthURI: auth.MakeTOTPURI(secret, user.Email),
+	}
+	helpers.RespondWithJson(respWriter, 201, resp)
+}
+
+func (usersHandler *UsersHandler) HandlerConfirmTwoFactor(respWriter http.ResponseWriter, req *http.Request) {
+	userId, err := usersHandler.validateAuthToken(req.Header.Get("Authorization"), &respWriter)
+	if err != nil {
+		return
+	}
+	reqBody := struct {
+		Code string `json:"code"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	if reqBody.Code == "" {
+		helpers.RespondWithError(respWriter, 400, "Code cannot be empty.")
+		return
+	}
+	totp, err := usersHandler.DB.GetUserTOTP(req.Context(), userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 400, "Two factor enrollment has not been started.")
+			return
+		}
+		usersHandler.Logger.Printf("Error trying to get totp settings for user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if totp.Enabled {
+		helpers.RespondWithError(respWriter, 409, "Two factor authentication is already enabled.")
+		return
+	}
+	step, err := auth.ValidateTOTP(reqBody.Code, totp.Secret, time.Now())
+	if err != nil {
+		helpers.RespondWithError(respWriter, 401, "Invalid code.")
+		return
+	}
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to generate recovery codes: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	// Old codes are replaced and 2FA enabled together, so a failure never
+	// leaves the user with only some of their codes.
+	err = withTx(req.Context(), usersHandler.ApiConfig, func(qtx *database.Queries) error {
+		err := qtx.DeleteRecoveryCodes(req.Context(), userId)
+		if err != nil {
+			return err
+		}
+		for _, code := range codes {
+			err = qtx.CreateRecoveryCode(req.Context(), database.CreateRecoveryCodeParams{
+				UserID:   userId,
+				CodeHash: auth.HashToken(code),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return qtx.EnableUserTOTP(req.Context(), database.EnableUserTOTPParams{LastUsedStep: step, UserID: userId})
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to enable totp for user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{RecoveryCodes: codes}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (usersHandler *UsersHandler) respondWithTwoFactorChallenge(respWriter http.ResponseWriter, req *http.Request, userId uuid.UUID) {
+	challenge, err := usersHandler.DB.CreateTwoFactorChallenge(req.Context(), database.CreateTwoFactorChallengeParams{
+		Token:     auth.MakeRefreshToken(),
+		UserID:    userId,
+		ExpiresAt: time.Now().Add(twoFactorChallengeExpiry),
+	})
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to create two factor challenge: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		TwoFactorRequired bool      `json:"two_factor_required"`
+		ChallengeToken    string    `json:"challenge_token"`
+		ExpiresAt         time.Time `json:"expires_at"`
+	}{
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge.Token,
+		ExpiresAt:         challenge.ExpiresAt,
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (usersHandler *UsersHandler) HandlerLoginTwoFactor(respWriter http.ResponseWriter, req *http.Request) {
+	reqBody := struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code"`
+	}{}
+	defer req.Body.Close()
+	err := json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	if reqBody.ChallengeToken == "" {
+		helpers.RespondWithError(respWriter, 400, "challenge_token cannot be empty.")
+		return
+	}
+	if reqBody.Code == "" && reqBody.RecoveryCode == "" {
+		helpers.RespondWithError(respWriter, 400, "Must provide code or recovery_code.")
+		return
+	}
+	challenge, err := usersHandler.DB.GetTwoFactorChallenge(req.Context(), reqBody.ChallengeToken)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 401, "Invalid challenge token.")
+			return
+		}
+		usersHandler.Logger.Printf("Error trying to get two factor challenge: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		usersHandler.DB.DeleteTwoFactorChallenge(req.Context(), challenge.Token)
+		helpers.RespondWithError(respWriter, 401, "Challenge expired. Please login again.")
+		return
+	}
+	// Every attempt is counted before the code is checked, in one statement,
+	// so concurrent guesses can't get past the limit.
+	_, err = usersHandler.DB.ClaimTwoFactorChallengeAttempt(req.Context(), database.ClaimTwoFactorChallengeAttemptParams{
+		Token:       challenge.Token,
+		MaxAttempts: twoFactorChallengeMaxAttempts,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			usersHandler.DB.DeleteTwoFactorChallenge(req.Context(), challenge.Token)
+			helpers.RespondWithError(respWriter, 401, "Challenge expired. Please login again.")
+			return
+		}
+		usersHandler.Logger.Printf("Error trying to record two factor attempt: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	totp, err := usersHandler.DB.GetUserTOTP(req.Context(), challenge.UserID)
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to get totp settings for user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if reqBody.Code != "" {
+		step, err := auth.ValidateTOTP(reqBody.Code, totp.Secret, time.Now())
+		if err != nil || step <= totp.LastUsedStep {
+			helpers.RespondWithError(respWriter, 401, "Invalid code.")
+			return
+		}
+		// Only moves forward, so of two logins racing with the same code
+		// just one updates the row.
+		updated, err := usersHandler.DB.UpdateTOTPLastUsedStep(req.Context(), database.UpdateTOTPLastUsedStepParams{LastUsedStep: step, UserID: totp.UserID})
+		if err != nil {
+			usersHandler.Logger.Printf("Error trying to update totp last used step: %v", err)
+			helpers.RespondWithError(respWriter, 500, "Internal server error.")
+			return
+		}
+		if updated == 0 {
+			helpers.RespondWithError(respWriter, 401, "Invalid code.")
+			return
+		}
+	} else {
+		recoveryCode := strings.ToLower(strings.TrimSpace(reqBody.RecoveryCode))
+		_, err := usersHandler.DB.UseRecoveryCode(req.Context(), database.UseRecoveryCodeParams{
+			UserID:   challenge.UserID,
+			CodeHash: auth.HashToken(recoveryCode),
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				helpers.RespondWithError(respWriter, 401, "Invalid code.")
+				return
+			}
+			usersHandler.Logger.Printf("Error trying to use recovery code: %v", err)
+			helpers.RespondWithError(respWriter, 500, "Internal server error.")
+			return
+		}
+	}
+	err = usersHandler.DB.DeleteTwoFactorChallenge(req.Context(), challenge.Token)
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to delete two factor challenge: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	user, err := usersHandler.DB.GetUser(req.Context(), challenge.UserID)
+	if err != nil {
+		usersHandler.Logger.Printf("Error trying to get user from db. UserId: %v Error : %v", challenge.UserID, err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	usersHandler.respondWithLoginTokens(respWriter, req, user)
+}
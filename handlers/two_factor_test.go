@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"Chirpy/internal/database"
+	"crypto/hmac"
+	"crypto/sha1"
+	"database/sql/driver"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// currentTOTPCode computes the code an authenticator app would show now.
+func currentTOTPCode(secret string) string {
+	key, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	counter := binary.BigEndian.AppendUint64(nil, uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func twoFactorLogin(db *fakeDB, code string) *httptest.ResponseRecorder {
+	usersHandler := UsersHandler{db.apiConfig()}
+	req := httptest.NewRequest("POST", "/api/login/2fa", strings.NewReader(`{"challenge_token": "challenge", "code": "`+code+`"}`))
+	respWriter := httptest.NewRecorder()
+	usersHandler.HandlerLoginTwoFactor(respWriter, req)
+	return respWriter
+}
+
+func setTwoFactorChallenge(db *fakeDB, user database.User, secret string) {
+	db.set("GetTwoFactorChallenge", fakeResult{
+		columns: []string{"token", "user_id", "attempts", "expires_at", "created_at"},
+		rows:    [][]driver.Value{{"challenge", user.ID.String(), int64(0), time.Now().Add(time.Minute), time.Now()}},
+	})
+	db.set("GetUserTOTP", fakeResult{
+		columns: []string{"user_id", "secret", "enabled", "last_used_step", "created_at", "updated_at"},
+		rows:    [][]driver.Value{{user.ID.String(), secret, true, int64(0), time.Now(), time.Now()}},
+	})
+	db.set("ClaimTwoFactorChallengeAttempt", fakeValue(int64(1)))
+	db.empty("DeleteTwoFactorChallenge")
+}
+
+func TestTwoFactorLoginRejectsExhaustedChallenge(t *testing.T) {
+	db := newFakeDB()
+	user := fakeUser("user@example.com")
+	setTwoFactorChallenge(db, user, "JBSWY3DPEHPK3PXP")
+	db.empty("ClaimTwoFactorChallengeAttempt")
+
+	respWriter := twoFactorLogin(db, currentTOTPCode("JBSWY3DPEHPK3PXP"))
+	if respWriter.Code != http.StatusUnauthorized || len(db.called("GetUserTOTP")) != 0 {
+		t.Errorf("Expected 401 before the code is checked, got %v: %v", respWriter.Code, respWriter.Body)
+		t.FailNow()
+	}
+}
+
+func TestTwoFactorLoginRejectsReplayedStep(t *testing.T) {
+	db := newFakeDB()
+	user := fakeUser("user@example.com")
+	setTwoFactorChallenge(db, user, "JBSWY3DPEHPK3PXP")
+	// Another login with the same code moved last_used_step first.
+	db.set("UpdateTOTPLastUsedStep", fakeResult{rowsAffected: 0})
+
+	respWriter := twoFactorLogin(db, currentTOTPCode("JBSWY3DPEHPK3PXP"))
+	if respWriter.Code != http.StatusUnauthorized || len(db.called("UpdateTOTPLastUsedStep")) != 1 {
+		t.Errorf("Expected 401 after trying to use the step, got %v: %v", respWriter.Code, respWriter.Body)
+		t.FailNow()
+	}
+	if len(db.called("DeleteTwoFactorChallenge")) != 0 || len(db.called("GetUser")) != 0 {
+		t.Errorf("Expected the replayed code not to log in.")
+		t.FailNow()
+	}
+}
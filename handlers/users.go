@@ -5,6 +5,7 @@ import (
 	"Chirpy/internal/auth"
 	"Chirpy/internal/config"
 	"Chirpy/internal/database"
+	"Chirpy/internal/moderation"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -102,6 +103,30 @@ func (usersHandler *UsersHandler) HandlerLogin(respWriter http.ResponseWriter, r
 		helpers.RespondWithError(respWriter, 401, "Incorrect Email or Password.")
 		return
 	}
+	if moderation.IsSuspended(user.SuspendedAt, user.SuspendedUntil, time.Now()) {
+		helpers.RespondWithError(respWriter, 403, moderation.SuspensionMessage(user.SuspendedUntil, user.SuspensionReason))
+		return
+	}
+	totp, err := usersHandler.DB.GetUserTOTP(req.Context(), user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		usersHandler.Logger.Printf("Error trying to get totp settings for user: %v", err)
+		helpers.RespondWithError(respWriter, 500, "500 Something went wrong.")
+		return
+	}
+	if err == nil && totp.Enabled {
+		usersHandler.respondWithTwoFactorChallenge(respWriter, req, user.ID)
+		return
+	}
+	usersHandler.respondWithLoginTokens(respWriter, req, user)
+}
+
+// respondWithLoginTokens finishes every kind of login. Suspended users are
+// turned away here too, for logins that didn't pass through HandlerLogin.
+func (usersHandler *UsersHandler) respondWithLoginTokens(respWriter http.ResponseWriter, req *http.Request, user database.User) {
+	if moderation.IsSuspended(user.SuspendedAt, user.SuspendedUntil, time.Now()) {
+		helpers.RespondWithError(respWriter, 403, moderation.SuspensionMessage(user.SuspendedUntil, user.SuspensionReason))
+		return
+	}
 	tokenExpiry := time.Duration(1) * time.Hour
 	token, err := auth.MakeJWT(user.ID, usersHandler.ApiConfig.JWTSecret, tokenExpiry)
 	if err != nil {
@@ -213,6 +238,10 @@ func (usersHandler *UsersHandler) HandlerRefresh(respWriter http.ResponseWriter,
 		helpers.RespondWithError(respWriter, 401, "No User found for the given token. Please try again.")
 		return
 	}
+	if moderation.IsSuspended(user.SuspendedAt, user.SuspendedUntil, time.Now()) {
+		helpers.RespondWithError(respWriter, 403, moderation.SuspensionMessage(user.SuspendedUntil, user.SuspensionReason))
+		return
+	}
 	newToken, err := auth.MakeJWT(user.ID, usersHandler.JWTSecret, time.Duration(1)*time.Hour)
 	if err != nil {
 		usersHandler.Logger.Printf("Error trying to create new jwt token: %v", err)
@@ -259,60 +288,6 @@ func (usersHandler *UsersHandler) HandlerRevoke(respWriter http.ResponseWriter,
 	respWriter.WriteHeader(http.StatusNoContent)
 }
 
-func (usersHandler *UsersHandler) HandlerUpgradeUser(respWriter http.ResponseWriter, req *http.Request) {
-	apiKey, err := auth.GetAPIKey(req.Header)
-	if err != nil {
-		helpers.RespondWithError(respWriter, 400, "Invalid Api Key.")
-		return
-	}
-	if apiKey != usersHandler.PolkaKey {
-		helpers.RespondWithError(respWriter, 400, "Invalid Api Key.")
-		return
-	}
-	reqBody := struct {
-		Event string `json:"event"`
-		Data  struct {
-			UserId string `json:"user_id"`
-		} `json:"data"`
-	}{}
-	defer req.Body.Close()
-	err = json.NewDecoder(req.Body).Decode(&reqBody)
-	if err != nil {
-		helpers.RespondWithError(respWriter, 400, "Invalid request.")
-		return
-	}
-	if reqBody.Event == "" || reqBody.Data.UserId == "" {
-		helpers.RespondWithError(respWriter, 400, "Invalid request body.")
-		return
-
-	}
-	if reqBody.Event != "user.upgraded" {
-		helpers.RespondWithJson(respWriter, 204, "")
-		return
-	}
-	userId, err := uuid.Parse(reqBody.Data.UserId)
-	if err != nil {
-		helpers.RespondWithError(respWriter, 400, "Invalid user_id.")
-		return
-	}
-
-	upgradedUser, err := usersHandler.DB.UpgradeUserToRed(req.Context(), userId)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			helpers.RespondWithError(respWriter, 404, "Invalid request. No user found for given user_id.")
-			return
-		}
-		usersHandler.Logger.Printf("Error upgrading the user to chirpy_red: %v", err)
-		helpers.RespondWithError(respWriter, 500, "Internal server error. Please try again.")
-		return
-	}
-	if upgradedUser.ID == uuid.Nil {
-		helpers.RespondWithError(respWriter, 404, "Invalid request. No user found for given user_id.")
-		return
-	}
-	helpers.RespondWithJson(respWriter, 204, "")
-}
-
 func (usersHandler *UsersHandler) validateRefreshToken(authToken string, respWriter *http.ResponseWriter, req *http.Request) (refreshToken database.RefreshToken, err error) {
 	if authToken == "" {
 		helpers.RespondWithError(*respWriter, http.StatusBadRequest, "No Authorization header passed in request.")
@@ -341,6 +316,10 @@ func (usersHandler *UsersHandler) validateRefreshToken(authToken string, respWri
 		helpers.RespondWithError(*respWriter, 401, "Session expired. Please login again.")
 		return refreshToken, fmt.Errorf("Invalid Token")
 	}
+	if refreshToken.RevokedAt.Valid {
+		helpers.RespondWithError(*respWriter, 401, "Session revoked. Please login again.")
+		return refreshToken, fmt.Errorf("Invalid Token")
+	}
 	return refreshToken, nil
 }
 func (usersHandler *UsersHandler) validateAuthToken(authToken string, respWriter *http.ResponseWriter) (userId uuid.UUID, err error) {
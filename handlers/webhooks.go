@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/config"
+	"Chirpy/internal/database"
+	"Chirpy/internal/webhooks"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	webhookDeliveriesDefaultLimit = 50
+	webhookDeliveriesMaxLimit     = 200
+)
+
+type WebhookHandler struct {
+	*config.ApiConfig
+}
+
+type webhookResponse struct {
+	Id        uuid.UUID  `json:"id"`
+	UserId    *uuid.UUID `json:"user_id"`
+	Url       string     `json:"url"`
+	Events    []string   `json:"events"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func newWebhookResponse(subscription database.WebhookSubscription) webhookResponse {
+	resp := webhookResponse{
+		Id:        subscription.ID,
+		Url:       subscription.Url,
+		Events:    subscription.Events,
+		Active:    subscription.Active,
+		CreatedAt: subscription.CreatedAt,
+	}
+	if subscription.UserID.Valid {
+		resp.UserId = &subscription.UserID.UUID
+	}
+	return resp
+}
+
+type webhookDeliveryResponse struct {
+	Id             uuid.UUID       `json:"id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastStatusCode *int32          `json:"last_status_code"`
+	LastError      *string         `json:"last_error"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func newWebhookDeliveryResponse(delivery database.WebhookDelivery) webhookDeliveryResponse {
+	resp := webhookDeliveryResponse{
+		Id:        delivery.ID,
+		Event:     delivery.Event,
+		Payload:   delivery.Payload,
+		Status:    delivery.Status,
+		Attempts:  delivery.Attempts,
+		CreatedAt: delivery.CreatedAt,
+	}
+	if delivery.Status == webhooks.StatusPending {
+		resp.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	if delivery.LastStatusCode.Valid {
+		resp.LastStatusCode = &delivery.LastStatusCode.Int32
+	}
+	if delivery.LastError.Valid {
+		resp.LastError = &delivery.LastError.String
+	}
+	if delivery.DeliveredAt.Valid {
+		resp.DeliveredAt = &delivery.DeliveredAt.Time
+	}
+	return resp
+}
+
+func (webhookHandler *WebhookHandler) HandlerCreateWebhook(respWriter http.ResponseWriter, req *http.Request) {
+	owner, err := authenticateWebhookOwner(webhookHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	reqBody := struct {
+		Url    string   `json:"url"`
+		Events []string `json:"events"`
+	}{}
+	defer req.Body.Close()
+	err = json.NewDecoder(req.Body).Decode(&reqBody)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid request.")
+		return
+	}
+	err = webhooks.ValidateURL(reqBody.Url)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	err = webhooks.ValidateEvents(reqBody.Events)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, err.Error())
+		return
+	}
+	secret := "whsec_" + auth.MakeRefreshToken()
+	subscription, err := webhookHandler.DB.CreateWebhookSubscription(req.Context(), database.CreateWebhookSubscriptionParams{
+		UserID: owner,
+		Url:    reqBody.Url,
+		Secret: secret,
+		Events: reqBody.Events,
+	})
+	if err != nil {
+		webhookHandler.Logger.Printf("Error trying to create webhook subscription: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := struct {
+		webhookResponse
+		Secret string `json:"secret"`
+	}{
+		webhookResponse: newWebhookResponse(subscription),
+		Secret:          secret,
+	}
+	helpers.RespondWithJson(respWriter, 201, resp)
+}
+
+func (webhookHandler *WebhookHandler) HandlerGetWebhooks(respWriter http.ResponseWriter, req *http.Request) {
+	owner, err := authenticateWebhookOwner(webhookHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	subscriptions, err := webhookHandler.DB.GetWebhookSubscriptionsForOwner(req.Context(), owner)
+	if err != nil {
+		webhookHandler.Logger.Printf("Error trying to get webhook subscriptions: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := make([]webhookResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		resp = append(resp, newWebhookResponse(subscription))
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+func (webhookHandler *WebhookHandler) HandlerDeleteWebhook(respWriter http.ResponseWriter, req *http.Request) {
+	owner, err := authenticateWebhookOwner(webhookHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return
+	}
+	webhookId, err := uuid.Parse(req.PathValue("webhookID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid webhookID.")
+		return
+	}
+	deleted, err := webhookHandler.DB.DeleteWebhookSubscription(req.Context(), database.DeleteWebhookSubscriptionParams{ID: webhookId, UserID: owner})
+	if err != nil {
+		webhookHandler.Logger.Printf("Error trying to delete webhook subscription: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if deleted == 0 {
+		helpers.RespondWithError(respWriter, 404, "No webhook found for the given webhookID.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+// HandlerGetWebhookDeliveries returns the delivery log for one subscription,
+// newest first, including dead deliveries that gave up retrying.
+func (webhookHandler *WebhookHandler) HandlerGetWebhookDeliveries(respWriter http.ResponseWriter, req *http.Request) {
+	subscription, ok := webhookHandler.getOwnedWebhook(respWriter, req)
+	if !ok {
+		return
+	}
+	limit := webhookDeliveriesDefaultLimit
+	if rawLimit := req.URL.Query().Get("limit"); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed < 1 || parsed > webhookDeliveriesMaxLimit {
+			helpers.RespondWithError(respWriter, 400, "Invalid limit.")
+			return
+		}
+		limit = parsed
+	}
+	deliveries, err := webhookHandler.DB.GetWebhookDeliveries(req.Context(), database.GetWebhookDeliveriesParams{
+		SubscriptionID: subscription.ID,
+		Limit:          int32(limit),
+	})
+	if err != nil {
+		webhookHandler.Logger.Printf("Error trying to get webhook deliveries: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	resp := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		resp = append(resp, newWebhookDeliveryResponse(delivery))
+	}
+	helpers.RespondWithJson(respWriter, 200, resp)
+}
+
+// HandlerRetryWebhookDelivery moves a dead delivery back to pending so the
+// dispatcher picks it up again with a fresh set of attempts.
+func (webhookHandler *WebhookHandler) HandlerRetryWebhookDelivery(respWriter http.ResponseWriter, req *http.Request) {
+	subscription, ok := webhookHandler.getOwnedWebhook(respWriter, req)
+	if !ok {
+		return
+	}
+	deliveryId, err := uuid.Parse(req.PathValue("deliveryID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid deliveryID.")
+		return
+	}
+	requeued, err := webhookHandler.DB.RequeueWebhookDelivery(req.Context(), database.RequeueWebhookDeliveryParams{
+		ID:             deliveryId,
+		SubscriptionID: subscription.ID,
+	})
+	if err != nil {
+		webhookHandler.Logger.Printf("Error trying to requeue webhook delivery: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return
+	}
+	if requeued == 0 {
+		helpers.RespondWithError(respWriter, 404, "No dead delivery found for the given deliveryID.")
+		return
+	}
+	respWriter.WriteHeader(http.StatusNoContent)
+}
+
+func (webhookHandler *WebhookHandler) getOwnedWebhook(respWriter http.ResponseWriter, req *http.Request) (database.WebhookSubscription, bool) {
+	owner, err := authenticateWebhookOwner(webhookHandler.ApiConfig, respWriter, req)
+	if err != nil {
+		return database.WebhookSubscription{}, false
+	}
+	webhookId, err := uuid.Parse(req.PathValue("webhookID"))
+	if err != nil {
+		helpers.RespondWithError(respWriter, 400, "Invalid webhookID.")
+		return database.WebhookSubscription{}, false
+	}
+	subscription, err := webhookHandler.DB.GetWebhookSubscription(req.Context(), webhookId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.RespondWithError(respWriter, 404, "No webhook found for the given webhookID.")
+			return database.WebhookSubscription{}, false
+		}
+		webhookHandler.Logger.Printf("Error trying to get webhook subscription: %v", err)
+		helpers.RespondWithError(respWriter, 500, "Internal server error.")
+		return database.WebhookSubscription{}, false
+	}
+	if subscription.UserID != owner {
+		helpers.RespondWithError(respWriter, 404, "No webhook found for the given webhookID.")
+		return database.WebhookSubscription{}, false
+	}
+	return subscription, true
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"Chirpy/helpers"
+	"Chirpy/internal/auth"
+	"Chirpy/internal/stream"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteTimeout   = 10 * time.Second
+	wsPongTimeout    = 60 * time.Second
+	wsPingInterval   = 25 * time.Second
+	wsMaxMessageSize = 4096
+	wsMaxChannels    = 50
+	wsReplyBuffer    = 16
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Connections authenticate with a JWT rather than cookies, so another
+	// origin can't open a socket as the user without already having the token.
+	CheckOrigin: func(req *http.Request) bool { return true },
+}
+
+type wsClientMessage struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+}
+
+type wsServerMessage struct {
+	Type    string          `json:"type"`
+	Channel string          `json:"channel,omitempty"`
+	Event   string          `json:"event,omitempty"`
+	Id      uint64          `json:"id,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// wsSession holds the channels one connection is subscribed to. matches is
+// called by the hub while it publishes, so it must not block.
+type wsSession struct {
+	userId    uuid.UUID
+	mu        sync.Mutex
+	channels  map[string]stream.Channel
+	following map[uuid.UUID]bool
+}
+
+func (session *wsSession) matches(event stream.Event) bool {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	for _, channel := range session.channels {
+		if channel.Matches(event, session.userId, session.following) {
+			return true
+		}
+	}
+	return false
+}
+
+// HandlerWebSocket upgrades to a WebSocket that delivers chirp events and
+// notifications for the channels the client subscribes to. The JWT goes in
+// the Authorization header or, for browsers, the token query parameter.
+func (chirpHanlder *ChirpHandler) HandlerWebSocket(respWriter http.ResponseWriter, req *http.Request) {
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		token = req.URL.Query().Get("token")
+	}
+	userId, err := auth.ValidateJWT(token, chirpHanlder.JWTSecret)
+	if err != nil {
+		helpers.RespondWithError(respWriter, 401, "Invalid auth token")
+		return
+	}
+	conn, err := wsUpgrader.Upgrade(respWriter, req, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	session := &wsSession{userId: userId, channels: map[string]stream.Channel{}}
+	subscription, _ := chirpHanlder.Stream.Subscribe(0, session.matches)
+	defer chirpHanlder.Stream.Unsubscribe(subscription)
+	replies := make(chan wsServerMessage, wsReplyBuffer)
+	done := make(chan struct{})
+	go chirpHanlder.readWebSocket(req.Context(), conn, session, replies, done)
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case reply := <-replies:
+			err = writeWebSocket(conn, reply)
+		case event, ok := <-subscription.Events():
+			if !ok {
+				// The hub dropped us because the client isn't keeping up.
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "Too slow. Please reconnect."), time.Now().Add(wsWriteTimeout))
+				return
+			}
+			err = writeWebSocket(conn, wsServerMessage{Type: "event", Event: event.Type, Id: event.ID, Data: event.Data})
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func writeWebSocket(conn *websocket.Conn, message wsServerMessage) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteJSON(message)
+}
+
+// readWebSocket handles subscribe, unsubscribe and ping messages until the
+// connection fails or the client stops answering pings. A client that sends
+// faster than it reads its replies is disconnected.
+func (chirpHanlder *ChirpHandler) readWebSocket(ctx context.Context, conn *websocket.Conn, session *wsSession, replies chan<- wsServerMessage, done chan<- struct{}) {
+	defer close(done)
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		message := wsClientMessage{}
+		reply := wsServerMessage{}
+		if json.Unmarshal(data, &message) != nil {
+			reply = wsServerMessage{Type: "error", Message: "Invalid message."}
+		} else {
+			reply = chirpHanlder.handleWebSocketMessage(ctx, session, message)
+		}
+		select {
+		case replies <- reply:
+		default:
+			return
+		}
+	}
+}
+
+func (chirpHanlder *ChirpHandler) handleWebSocketMessage(ctx context.Context, session *wsSession, message wsClientMessage) wsServerMessage {
+	switch message.Type {
+	case "ping":
+		return wsServerMessage{Type: "pong"}
+	case "subscribe", "unsubscribe":
+	default:
+		return wsServerMessage{Type: "error", Message: fmt.Sprintf("Unknown message type %v.", message.Type)}
+	}
+	channel, err := stream.ParseChannel(message.Channel)
+	if err != nil {
+		return wsServerMessage{Type: "error", Channel: message.Channel, Message: err.Error()}
+	}
+	if message.Type == "unsubscribe" {
+		session.mu.Lock()
+		delete(session.channels, channel.String())
+		session.mu.Unlock()
+		return wsServerMessage{Type: "unsubscribed", Channel: channel.String()}
+	}
+	var following map[uuid.UUID]bool
+	if channel.Kind == stream.ChannelTimeline {
+		followeeIds, err := chirpHanlder.DB.GetUnmutedFolloweeIDs(ctx, session.userId)
+		if err != nil {
+			chirpHanlder.Logger.Printf("Error getting followed users from db: %v", err)
+			return wsServerMessage{Type: "error", Channel: channel.String(), Message: "Internal server error."}
+		}
+		following = map[uuid.UUID]bool{}
+		for _, followeeId := range followeeIds {
+			following[followeeId] = true
+		}
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if _, ok := session.channels[channel.String()]; !ok && len(session.channels) >= wsMaxChannels {
+		return wsServerMessage{Type: "error", Channel: channel.String(), Message: fmt.Sprintf("Too many channels. The limit is %v.", wsMaxChannels)}
+	}
+	if following != nil {
+		session.following = following
+	}
+	session.channels[channel.String()] = channel
+	return wsServerMessage{Type: "subscribed", Channel: channel.String()}
+}
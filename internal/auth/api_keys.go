@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+)
+
+const (
+	ScopeChirpsRead  = "chirps:read"
+	ScopeChirpsWrite = "chirps:write"
+
+	apiKeyPrefix = "chirpy_"
+)
+
+var validScopes = []string{ScopeChirpsRead, ScopeChirpsWrite}
+
+// MakeAPIKey returns a new personal api key and the short prefix that is
+// stored in plain text so users can tell their keys apart.
+func MakeAPIKey() (key string, prefix string, err error) {
+	b := make([]byte, 32)
+	_, err = rand.Read(b)
+	if err != nil {
+		return "", "", fmt.Errorf("Unable to generate api key: %w", err)
+	}
+	key = apiKeyPrefix + hex.EncodeToString(b)
+	return key, key[:len(apiKeyPrefix)+8], nil
+}
+
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("At least one scope is required.")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(validScopes, scope) {
+			return fmt.Errorf("Unknown scope: %v", scope)
+		}
+	}
+	return nil
+}
+
+func HasScope(scopes []string, scope string) bool {
+	return slices.Contains(scopes, scope)
+}
@@ -116,3 +116,142 @@ func TestGetBearerToken(t *testing.T) {
 	}
 
 }
+
+// Test vectors from RFC 6238 truncated to six digits.
+func TestValidateTOTP(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+	}
+	for _, c := range cases {
+		step, err := ValidateTOTP(c.code, secret, time.Unix(c.unix, 0))
+		if err != nil {
+			t.Errorf("Valid code %v rejected at %v: %v", c.code, c.unix, err)
+			t.FailNow()
+		}
+		if step != c.unix/30 {
+			t.Errorf("Invalid step returned by ValidateTOTP. Expected: %v, Actual: %v", c.unix/30, step)
+			t.FailNow()
+		}
+	}
+	_, err := ValidateTOTP("287082", secret, time.Unix(59+120, 0))
+	if err == nil {
+		t.Error("Code accepted outside of the allowed time window.")
+		t.FailNow()
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Errorf("Couldn't generate totp secret: %v", err)
+		t.FailNow()
+	}
+	key, _ := totpEncoding.DecodeString(secret)
+	now := time.Now()
+	_, err = ValidateTOTP(totpCode(key, now.Unix()/30), secret, now)
+	if err != nil {
+		t.Errorf("Code generated for new secret was rejected: %v", err)
+		t.FailNow()
+	}
+	if !strings.HasPrefix(MakeTOTPURI(secret, "user@example.com"), "otpauth://totp/Chirpy:user@example.com?") {
+		t.Errorf("Invalid otpauth uri: %v", MakeTOTPURI(secret, "user@example.com"))
+		t.FailNow()
+	}
+}
+
+func TestMakeAPIKey(t *testing.T) {
+	key, prefix, err := MakeAPIKey()
+	if err != nil {
+		t.Errorf("Couldn't create api key: %v", err)
+		t.FailNow()
+	}
+	if !strings.HasPrefix(key, prefix) {
+		t.Errorf("Api key %v does not start with its prefix %v", key, prefix)
+		t.FailNow()
+	}
+	request, _ := http.NewRequest("GET", "/api/healthz", bytes.NewReader([]byte("")))
+	request.Header.Add("Authorization", "ApiKey "+key)
+	headerKey, err := GetAPIKey(request.Header)
+	if err != nil || HashToken(headerKey) != HashToken(key) {
+		t.Errorf("Api key parsed from header doesn't match. Expected: %v, Actual: %v", key, headerKey)
+		t.FailNow()
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	if err := ValidateScopes([]string{ScopeChirpsRead, ScopeChirpsWrite}); err != nil {
+		t.Errorf("Valid scopes rejected: %v", err)
+		t.FailNow()
+	}
+	if err := ValidateScopes([]string{"users:admin"}); err == nil {
+		t.Error("Unknown scope was accepted.")
+		t.FailNow()
+	}
+	if err := ValidateScopes(nil); err == nil {
+		t.Error("Empty scope list was accepted.")
+		t.FailNow()
+	}
+}
+
+func TestVerifyPKCE(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mJ92K9qB5f8HZ5w8_ZKxT0N3xuAfG8"
+	challenge := "5N-MGGt0zjacB7oblR6EMF1lNzBTC2pVf-fbIu1Ubow"
+	if !VerifyPKCE(verifier, challenge) {
+		t.Error("Valid code_verifier was rejected.")
+		t.FailNow()
+	}
+	if VerifyPKCE(strings.Repeat("a", 43), challenge) {
+		t.Error("Invalid code_verifier was accepted.")
+		t.FailNow()
+	}
+}
+
+func TestAccessToken(t *testing.T) {
+	token := MakeAccessToken()
+	if !IsAccessToken(token) {
+		t.Errorf("Token returned by MakeAccessToken not recognised: %v", token)
+		t.FailNow()
+	}
+	jwtToken, _ := MakeJWT(uuid.New(), TokenSecret, TokenValidityDuration)
+	if IsAccessToken(jwtToken) {
+		t.Error("JWT recognised as oauth access token.")
+		t.FailNow()
+	}
+	scopes := ParseScopes(" chirps:read  chirps:write ")
+	if FormatScopes(scopes) != "chirps:read chirps:write" {
+		t.Errorf("Invalid scopes parsed: %v", scopes)
+		t.FailNow()
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"id":"evt_1","event":"user.upgraded","data":{"user_id":"3311741c-680c-4546-99f3-fc9efac2036c"}}`)
+	now := time.Now()
+	header := SignWebhook("polka-secret", now, body)
+	err := VerifyWebhookSignature("polka-secret", header, body, now, 5*time.Minute)
+	if err != nil {
+		t.Errorf("Valid signature rejected: %v", err)
+		t.FailNow()
+	}
+	err = VerifyWebhookSignature("other-secret", header, body, now, 5*time.Minute)
+	if err == nil {
+		t.Error("Signature with wrong secret accepted.")
+		t.FailNow()
+	}
+	err = VerifyWebhookSignature("polka-secret", header, append(body, ' '), now, 5*time.Minute)
+	if err == nil {
+		t.Error("Signature accepted for a modified body.")
+		t.FailNow()
+	}
+	err = VerifyWebhookSignature("polka-secret", header, body, now.Add(10*time.Minute), 5*time.Minute)
+	if err == nil {
+		t.Error("Signature accepted outside of the timestamp tolerance.")
+		t.FailNow()
+	}
+}
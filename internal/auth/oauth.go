@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+)
+
+const accessTokenPrefix = "chirpy_at_"
+
+// MakeAccessToken returns an opaque OAuth access token. Only its hash is stored,
+// the prefix lets request authentication tell it apart from a JWT.
+func MakeAccessToken() string {
+	return accessTokenPrefix + MakeRefreshToken()
+}
+
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, accessTokenPrefix)
+}
+
+// VerifyPKCE checks a code_verifier against an S256 code_challenge (RFC 7636).
+func VerifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	expected := MakePKCEChallenge(verifier)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+func MakePKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ParseScopes splits a space delimited OAuth scope string.
+func ParseScopes(scope string) []string {
+	return strings.Fields(scope)
+}
+
+func FormatScopes(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
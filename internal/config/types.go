@@ -2,6 +2,12 @@ package config
 
 import (
 	"Chirpy/internal/database"
+	"Chirpy/internal/media"
+	"Chirpy/internal/oidc"
+	"Chirpy/internal/outbox"
+	"Chirpy/internal/ratelimit"
+	"Chirpy/internal/stream"
+	"database/sql"
 	"log"
 	"sync/atomic"
 )
@@ -9,8 +15,15 @@ import (
 type ApiConfig struct {
 	Logger         *log.Logger
 	DB             *database.Queries
+	SQLDB          *sql.DB
 	Platform       string
 	JWTSecret      string
 	PolkaKey       string
+	AdminKey       string
+	OIDC           *oidc.Provider
+	RateLimiter    *ratelimit.Limiter
+	Outbox         *outbox.Dispatcher
+	Stream         *stream.Hub
+	Media          media.BlobStore
 	FileServerHits atomic.Int32
 }
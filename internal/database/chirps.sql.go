@@ -7,21 +7,24 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps(id, created_at, updated_at, body, user_id)VALUES( gen_random_uuid(), Now(), Now(), $1, $2) returning id, created_at, updated_at, body, user_id
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, reply_to_id)VALUES( gen_random_uuid(), Now(), Now(), $1, $2, $3) returning id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body      string
+	UserID    uuid.UUID
+	ReplyToID uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.ReplyToID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -29,12 +32,48 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Published,
+		&i.PublishAt,
+		&i.EditedAt,
+		&i.ReplyToID,
+		&i.HiddenAt,
+		&i.LinkUrl,
+	)
+	return i, err
+}
+
+const createScheduledChirp = `-- name: CreateScheduledChirp :one
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, reply_to_id, published, publish_at) VALUES(gen_random_uuid(), $1, Now(), $2, $3, $4, false, $1) returning id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url
+`
+
+type CreateScheduledChirpParams struct {
+	PublishAt time.Time
+	Body      string
+	UserID    uuid.UUID
+	ReplyToID uuid.NullUUID
+}
+
+func (q *Queries) CreateScheduledChirp(ctx context.Context, arg CreateScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createScheduledChirp, arg.PublishAt, arg.Body, arg.UserID, arg.ReplyToID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Published,
+		&i.PublishAt,
+		&i.EditedAt,
+		&i.ReplyToID,
+		&i.HiddenAt,
+		&i.LinkUrl,
 	)
 	return i, err
 }
 
 const deleteChirp = `-- name: DeleteChirp :one
-DELETE  from chirps where id = $1 returning id, created_at, updated_at, body, user_id
+DELETE  from chirps where id = $1 returning id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url
 `
 
 func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -46,12 +85,74 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Published,
+		&i.PublishAt,
+		&i.EditedAt,
+		&i.ReplyToID,
+		&i.HiddenAt,
+		&i.LinkUrl,
+	)
+	return i, err
+}
+
+const deleteOwnChirp = `-- name: DeleteOwnChirp :one
+DELETE from chirps where id = $1 and user_id = $2 returning id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url
+`
+
+type DeleteOwnChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteOwnChirp(ctx context.Context, arg DeleteOwnChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, deleteOwnChirp, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Published,
+		&i.PublishAt,
+		&i.EditedAt,
+		&i.ReplyToID,
+		&i.HiddenAt,
+		&i.LinkUrl,
+	)
+	return i, err
+}
+
+const deleteScheduledChirp = `-- name: DeleteScheduledChirp :one
+DELETE from chirps where id = $1 and user_id = $2 and published = false returning id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url
+`
+
+type DeleteScheduledChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteScheduledChirp(ctx context.Context, arg DeleteScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, deleteScheduledChirp, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Published,
+		&i.PublishAt,
+		&i.EditedAt,
+		&i.ReplyToID,
+		&i.HiddenAt,
+		&i.LinkUrl,
 	)
 	return i, err
 }
 
 const getAllChirps = `-- name: GetAllChirps :many
-select id, created_at, updated_at, body, user_id from chirps order by created_at asc
+select id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url from chirps where published = true and hidden_at IS NULL order by created_at asc
 `
 
 func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
@@ -69,6 +170,12 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.Published,
+			&i.PublishAt,
+			&i.EditedAt,
+			&i.ReplyToID,
+			&i.HiddenAt,
+			&i.LinkUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -84,7 +191,7 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpsByAuthor = `-- name: GetChirpsByAuthor :many
-select id, created_at, updated_at, body, user_id from chirps where user_id= $1 order by created_at asc
+select id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url from chirps where user_id= $1 and published = true and hidden_at IS NULL order by created_at asc
 `
 
 func (q *Queries) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
@@ -102,6 +209,12 @@ func (q *Queries) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.Published,
+			&i.PublishAt,
+			&i.EditedAt,
+			&i.ReplyToID,
+			&i.HiddenAt,
+			&i.LinkUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -117,7 +230,7 @@ func (q *Queries) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Ch
 }
 
 const getOneChirp = `-- name: GetOneChirp :one
-select id, created_at, updated_at, body, user_id from chirps where id = $1 LIMIT 1
+select id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url from chirps where id = $1 LIMIT 1
 `
 
 func (q *Queries) GetOneChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -129,6 +242,157 @@ func (q *Queries) GetOneChirp(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Published,
+		&i.PublishAt,
+		&i.EditedAt,
+		&i.ReplyToID,
+		&i.HiddenAt,
+		&i.LinkUrl,
+	)
+	return i, err
+}
+
+const getScheduledChirps = `-- name: GetScheduledChirps :many
+select id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url from chirps where user_id = $1 and published = false order by publish_at asc
+`
+
+func (q *Queries) GetScheduledChirps(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getScheduledChirps, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Published,
+			&i.PublishAt,
+			&i.EditedAt,
+			&i.ReplyToID,
+			&i.HiddenAt,
+			&i.LinkUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVisibleChirpsByIDs = `-- name: GetVisibleChirpsByIDs :many
+select id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url from chirps where id = ANY($1::uuid[]) and published = true and hidden_at IS NULL
+`
+
+func (q *Queries) GetVisibleChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getVisibleChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Published,
+			&i.PublishAt,
+			&i.EditedAt,
+			&i.ReplyToID,
+			&i.HiddenAt,
+			&i.LinkUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+UPDATE chirps set published = true, updated_at = Now() where published = false and publish_at <= Now() returning id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url
+`
+
+func (q *Queries) PublishDueChirps(ctx context.Context) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Published,
+			&i.PublishAt,
+			&i.EditedAt,
+			&i.ReplyToID,
+			&i.HiddenAt,
+			&i.LinkUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps set body = $1, edited_at = Now(), updated_at = Now() where id = $2 returning id, created_at, updated_at, body, user_id, published, publish_at, edited_at, reply_to_id, hidden_at, link_url
+`
+
+type UpdateChirpBodyParams struct {
+	Body string
+	ID   uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Published,
+		&i.PublishAt,
+		&i.EditedAt,
+		&i.ReplyToID,
+		&i.HiddenAt,
+		&i.LinkUrl,
 	)
 	return i, err
 }
@@ -6,17 +6,294 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	KeyHash    string
+	Prefix     string
+	Scopes     []string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type Attachment struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	ChirpID      uuid.NullUUID
+	Position     int32
+	ContentType  string
+	SizeBytes    int64
+	Width        int32
+	Height       int32
+	BlobKey      string
+	ThumbnailKey string
+	CreatedAt    time.Time
+	AttachedAt   sql.NullTime
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID       uuid.UUID
+	ChirpID      uuid.UUID
+	CollectionID uuid.NullUUID
+	CreatedAt    time.Time
+}
+
+type BookmarkCollection struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 type Chirp struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Body      string
 	UserID    uuid.UUID
+	Published bool
+	PublishAt sql.NullTime
+	EditedAt  sql.NullTime
+	ReplyToID uuid.NullUUID
+	HiddenAt  sql.NullTime
+	LinkUrl   sql.NullString
+}
+
+type ChirpRanking struct {
+	Rank     int32
+	ChirpID  uuid.UUID
+	Score    float64
+	RankedAt time.Time
+}
+
+type Conversation struct {
+	ID            uuid.UUID
+	CreatedBy     uuid.UUID
+	IsGroup       bool
+	Title         sql.NullString
+	CreatedAt     time.Time
+	LastMessageAt time.Time
+	DirectKey     sql.NullString
+}
+
+type ConversationMember struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type Draft struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Body      string
+	ReplyToID uuid.NullUUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Like struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type LinkPreview struct {
+	Url           string
+	Status        string
+	Title         sql.NullString
+	Description   sql.NullString
+	ImageUrl      sql.NullString
+	SiteName      sql.NullString
+	Attempts      int32
+	LastError     sql.NullString
+	NextAttemptAt time.Time
+	FetchedAt     sql.NullTime
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type Message struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+	CreatedAt      time.Time
+}
+
+type ModerationAction struct {
+	ID              uuid.UUID
+	ModeratorID     uuid.NullUUID
+	Action          string
+	ChirpID         uuid.NullUUID
+	TargetUserID    uuid.NullUUID
+	Note            sql.NullString
+	ReportsResolved int32
+	CreatedAt       time.Time
+}
+
+type Moderator struct {
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	EventID   uuid.UUID
+	Type      string
+	ActorID   uuid.NullUUID
+	ChirpID   uuid.NullUUID
+	ReadAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type NotificationPreference struct {
+	UserID    uuid.UUID
+	Mentions  bool
+	Replies   bool
+	Follows   bool
+	Likes     bool
+	ChirpyRed bool
+	UpdatedAt time.Time
+}
+
+type OauthAccessToken struct {
+	TokenHash string
+	ClientID  uuid.UUID
+	UserID    uuid.UUID
+	Scopes    []string
+	ExpiresAt time.Time
+	RevokedAt sql.NullTime
+	CreatedAt time.Time
+}
+
+type OauthAuthorizationCode struct {
+	CodeHash      string
+	ClientID      uuid.UUID
+	UserID        uuid.UUID
+	RedirectUri   string
+	Scopes        []string
+	CodeChallenge string
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+type OauthClient struct {
+	ID           uuid.UUID
+	OwnerID      uuid.UUID
+	Name         string
+	SecretHash   sql.NullString
+	RedirectUris []string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type OauthConsent struct {
+	UserID    uuid.UUID
+	ClientID  uuid.UUID
+	Scopes    []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type OidcLoginState struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+	LinkUserID   uuid.NullUUID
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+type OutboxEvent struct {
+	ID            uuid.UUID
+	Event         string
+	UserID        uuid.NullUUID
+	Payload       json.RawMessage
+	Attempts      int32
+	LastError     sql.NullString
+	NextAttemptAt time.Time
+	PublishedAt   sql.NullTime
+	CreatedAt     time.Time
+}
+
+type PinnedChirp struct {
+	ChirpID  uuid.UUID
+	UserID   uuid.UUID
+	PinnedAt time.Time
+}
+
+type PolkaDelivery struct {
+	ID         uuid.UUID
+	EventID    string
+	Event      string
+	Payload    json.RawMessage
+	Status     string
+	ReceivedAt time.Time
+}
+
+type PolkaEvent struct {
+	EventID   string
+	Event     string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	ClosesAt  time.Time
+	CreatedAt time.Time
+}
+
+type PollOption struct {
+	ID       uuid.UUID
+	ChirpID  uuid.UUID
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type RecoveryCode struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +305,91 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type Report struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	ReporterID uuid.UUID
+	Reason     string
+	Details    sql.NullString
+	Status     string
+	CreatedAt  time.Time
+	ResolvedAt sql.NullTime
+}
+
+type Subscription struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Plan      string
+	Status    string
+	StartedAt time.Time
+	RenewedAt sql.NullTime
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type TwoFactorChallenge struct {
+	Token     string
+	UserID    uuid.UUID
+	Attempts  int32
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
 type User struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Email            string
+	HashedPassword   string
+	IsChirpyRed      bool
+	SuspendedAt      sql.NullTime
+	SuspendedUntil   sql.NullTime
+	SuspensionReason sql.NullString
+}
+
+type UserIdentity struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Issuer    string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type UserTotp struct {
+	UserID       uuid.UUID
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type WebhookDelivery struct {
 	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	Event          string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastStatusCode sql.NullInt32
+	LastError      sql.NullString
+	DeliveredAt    sql.NullTime
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	EventID        uuid.NullUUID
+}
+
+type WebhookSubscription struct {
+	ID        uuid.UUID
+	UserID    uuid.NullUUID
+	Url       string
+	Secret    string
+	Events    []string
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
@@ -0,0 +1,35 @@
+// Package entitlements decides what a user is allowed to do based on their
+// Chirpy Red status. Handlers ask here instead of checking is_chirpy_red.
+package entitlements
+
+import "Chirpy/internal/database"
+
+type Entitlements struct {
+	MaxChirpLength    int
+	CanEditChirps     bool
+	CanScheduleChirps bool
+	ChirpsPerMinute   int
+	MaxPinnedChirps   int
+}
+
+var (
+	Free = Entitlements{
+		MaxChirpLength:  140,
+		ChirpsPerMinute: 10,
+		MaxPinnedChirps: 1,
+	}
+	Red = Entitlements{
+		MaxChirpLength:    500,
+		CanEditChirps:     true,
+		CanScheduleChirps: true,
+		ChirpsPerMinute:   60,
+		MaxPinnedChirps:   5,
+	}
+)
+
+func ForUser(user database.User) Entitlements {
+	if user.IsChirpyRed {
+		return Red
+	}
+	return Free
+}
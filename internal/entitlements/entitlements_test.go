@@ -0,0 +1,27 @@
+package entitlements
+
+import (
+	"Chirpy/internal/database"
+	"testing"
+)
+
+func TestForUser(t *testing.T) {
+	free := ForUser(database.User{})
+	if free.MaxChirpLength != 140 || free.CanEditChirps || free.CanScheduleChirps {
+		t.Errorf("Unexpected entitlements for free user: %+v", free)
+		t.FailNow()
+	}
+	red := ForUser(database.User{IsChirpyRed: true})
+	if red.MaxChirpLength <= free.MaxChirpLength || !red.CanEditChirps || !red.CanScheduleChirps {
+		t.Errorf("Unexpected entitlements for Chirpy Red user: %+v", red)
+		t.FailNow()
+	}
+	if red.ChirpsPerMinute <= free.ChirpsPerMinute {
+		t.Errorf("Chirpy Red rate limit %v is not higher than free limit %v.", red.ChirpsPerMinute, free.ChirpsPerMinute)
+		t.FailNow()
+	}
+	if free.MaxPinnedChirps < 1 || red.MaxPinnedChirps <= free.MaxPinnedChirps {
+		t.Errorf("Unexpected pin limits: free %v, Chirpy Red %v.", free.MaxPinnedChirps, red.MaxPinnedChirps)
+		t.FailNow()
+	}
+}
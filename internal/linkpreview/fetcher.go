@@ -0,0 +1,111 @@
+package linkpreview
+
+import (
+	"Chirpy/internal/netguard"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultTimeout  = 5 * time.Second
+	defaultMaxBytes = 512 << 10
+	maxRedirects    = 3
+)
+
+var (
+	ErrNotHTML    = errors.New("page is not html")
+	ErrNoMetadata = errors.New("page has no preview metadata")
+)
+
+type Options struct {
+	Timeout  time.Duration
+	MaxBytes int64
+	// AllowAddress decides which resolved addresses may be dialed. It
+	// defaults to netguard.IsPublicAddress; tests loosen it to reach httptest
+	// servers.
+	AllowAddress func(netip.AddrPort) bool
+}
+
+// Fetcher downloads pages for previews. Every connection is checked after DNS
+// resolution, including redirects, so links can't be used to reach the
+// server's internal network.
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+func NewFetcher(options Options) *Fetcher {
+	if options.Timeout == 0 {
+		options.Timeout = defaultTimeout
+	}
+	if options.MaxBytes == 0 {
+		options.MaxBytes = defaultMaxBytes
+	}
+	return &Fetcher{
+		client: &http.Client{
+			Transport: netguard.NewTransport(options.Timeout, options.AllowAddress),
+			Timeout:   options.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("stopped after %v redirects", maxRedirects)
+				}
+				return validateURL(req.URL)
+			},
+		},
+		maxBytes: options.MaxBytes,
+	}
+}
+
+func validateURL(target *url.URL) error {
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" || target.User != nil {
+		return fmt.Errorf("unsupported url %q", target.Redacted())
+	}
+	return nil
+}
+
+// Fetch downloads at most MaxBytes of the page at rawURL and parses its
+// preview. Only HTML pages with some metadata produce a preview.
+func (fetcher *Fetcher) Fetch(ctx context.Context, rawURL string) (Preview, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return Preview{}, err
+	}
+	err = validateURL(target)
+	if err != nil {
+		return Preview{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return Preview{}, err
+	}
+	req.Header.Set("User-Agent", "Chirpy-LinkPreview/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	resp, err := fetcher.client.Do(req)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Preview{}, fmt.Errorf("page responded with %v", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Preview{}, ErrNotHTML
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, fetcher.maxBytes))
+	if err != nil {
+		return Preview{}, err
+	}
+	preview := Parse(page, resp.Request.URL)
+	if preview.IsEmpty() {
+		return Preview{}, ErrNoMetadata
+	}
+	return preview, nil
+}
@@ -0,0 +1,123 @@
+// Package linkpreview unfurls links posted in chirps. A chirp's first URL is
+// queued in link_previews and a background job fetches the page's Open Graph
+// or Twitter card metadata, which doubles as a cache for later chirps.
+package linkpreview
+
+import (
+	"Chirpy/internal/validation"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	StatusPending = "pending"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+
+	// MaxAttempts is how many times a page is fetched before giving up.
+	MaxAttempts = 3
+
+	maxURLLength         = 2048
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+	maxSiteNameLength    = 100
+)
+
+type Preview struct {
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+var (
+	metaPattern  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	attrPattern  = regexp.MustCompile(`(?is)([a-z][a-z0-9:_-]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
+
+// FirstURL returns the first http or https URL in body, without trailing
+// punctuation, or "" if there is none.
+func FirstURL(body string) string {
+	for _, match := range validation.URLs(body) {
+		if len(match) > maxURLLength {
+			continue
+		}
+		parsed, err := url.Parse(match)
+		if err != nil || parsed.Host == "" || parsed.User != nil {
+			continue
+		}
+		return match
+	}
+	return ""
+}
+
+// Parse reads the preview from a page's meta tags, preferring Open Graph,
+// then Twitter cards, then the plain title and description. Relative image
+// URLs are resolved against base, the URL the page was finally served from.
+func Parse(page []byte, base *url.URL) Preview {
+	document := string(page)
+	if end := strings.Index(strings.ToLower(document), "</head>"); end >= 0 {
+		document = document[:end]
+	}
+	meta := map[string]string{}
+	for _, tag := range metaPattern.FindAllString(document, -1) {
+		attrs := map[string]string{}
+		for _, attr := range attrPattern.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(attr[1])] = attr[2] + attr[3] + attr[4]
+		}
+		key := attrs["property"]
+		if key == "" {
+			key = attrs["name"]
+		}
+		key = strings.ToLower(key)
+		if _, seen := meta[key]; key != "" && !seen {
+			meta[key] = attrs["content"]
+		}
+	}
+	title := ""
+	if match := titlePattern.FindStringSubmatch(document); match != nil {
+		title = match[1]
+	}
+	preview := Preview{
+		Title:       clean(firstOf(meta["og:title"], meta["twitter:title"], title), maxTitleLength),
+		Description: clean(firstOf(meta["og:description"], meta["twitter:description"], meta["description"]), maxDescriptionLength),
+		SiteName:    clean(meta["og:site_name"], maxSiteNameLength),
+	}
+	image := clean(firstOf(meta["og:image:secure_url"], meta["og:image"], meta["twitter:image"], meta["twitter:image:src"]), maxURLLength)
+	if image != "" {
+		resolved, err := base.Parse(image)
+		if err == nil && (resolved.Scheme == "http" || resolved.Scheme == "https") {
+			preview.ImageURL = resolved.String()
+		}
+	}
+	return preview
+}
+
+func (preview Preview) IsEmpty() bool {
+	return preview.Title == "" && preview.Description == "" && preview.ImageURL == ""
+}
+
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// clean decodes entities, collapses whitespace and cuts the text to maxLength
+// characters. Invalid UTF-8 and NUL bytes are dropped since Postgres rejects
+// them.
+func clean(text string, maxLength int) string {
+	text = strings.ToValidUTF8(html.UnescapeString(text), "")
+	text = strings.Join(strings.Fields(strings.ReplaceAll(text, "\x00", "")), " ")
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+	return string([]rune(text)[:maxLength-1]) + "…"
+}
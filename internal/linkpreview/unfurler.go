@@ -0,0 +1,65 @@
+package linkpreview
+
+import (
+	"Chirpy/internal/database"
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+const (
+	unfurlBatchSize = 10
+	retryDelay      = time.Minute
+)
+
+type Unfurler struct {
+	DB      *database.Queries
+	Fetcher *Fetcher
+	Logger  *log.Logger
+}
+
+// UnfurlDue fetches every queued preview that is due. Claimed rows are leased
+// for a few minutes so a crash mid-fetch only delays the retry.
+func (unfurler *Unfurler) UnfurlDue(ctx context.Context) error {
+	previews, err := unfurler.DB.ClaimDueLinkPreviews(ctx, unfurlBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, pending := range previews {
+		err = unfurler.unfurl(ctx, pending)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (unfurler *Unfurler) unfurl(ctx context.Context, pending database.LinkPreview) error {
+	preview, fetchErr := unfurler.Fetcher.Fetch(ctx, pending.Url)
+	if fetchErr == nil {
+		return unfurler.DB.MarkLinkPreviewReady(ctx, database.MarkLinkPreviewReadyParams{
+			Url:         pending.Url,
+			Title:       nullString(preview.Title),
+			Description: nullString(preview.Description),
+			ImageUrl:    nullString(preview.ImageURL),
+			SiteName:    nullString(preview.SiteName),
+		})
+	}
+	attempts := int(pending.Attempts) + 1
+	status := StatusPending
+	if attempts >= MaxAttempts || fetchErr == ErrNotHTML || fetchErr == ErrNoMetadata {
+		status = StatusFailed
+		unfurler.Logger.Printf("Giving up on link preview for %v after %v attempts: %v", pending.Url, attempts, fetchErr)
+	}
+	return unfurler.DB.MarkLinkPreviewFailed(ctx, database.MarkLinkPreviewFailedParams{
+		Url:           pending.Url,
+		Status:        status,
+		LastError:     sql.NullString{String: fetchErr.Error(), Valid: true},
+		NextAttemptAt: time.Now().Add(time.Duration(attempts) * retryDelay),
+	})
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,67 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformedGIF = errors.New("malformed GIF")
+
+// gifFrames walks the blocks of a GIF without decoding any pixels and returns
+// how many frames it has and their total area. gif.DecodeAll allocates every
+// frame up front, so this is what bounds its memory.
+func gifFrames(data []byte) (frames int, pixels int, err error) {
+	if len(data) < 13 {
+		return 0, 0, errMalformedGIF
+	}
+	pos := 13
+	if data[10]&0x80 != 0 {
+		pos += 3 << (data[10]&0x07 + 1)
+	}
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x3B:
+			return frames, pixels, nil
+		case 0x21:
+			pos, err = skipSubBlocks(data, pos+2)
+		case 0x2C:
+			if pos+10 > len(data) {
+				return 0, 0, errMalformedGIF
+			}
+			width := int(binary.LittleEndian.Uint16(data[pos+5:]))
+			height := int(binary.LittleEndian.Uint16(data[pos+7:]))
+			flags := data[pos+9]
+			frames++
+			pixels += width * height
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			// Skip the LZW minimum code size before the image data.
+			pos, err = skipSubBlocks(data, pos+1)
+		default:
+			return 0, 0, errMalformedGIF
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	// A truncated file is left for the decoder to reject.
+	return frames, pixels, nil
+}
+
+// skipSubBlocks returns the position after the sub-block chain starting at
+// pos, which ends with an empty block.
+func skipSubBlocks(data []byte, pos int) (int, error) {
+	for {
+		if pos >= len(data) {
+			return 0, errMalformedGIF
+		}
+		size := int(data[pos])
+		pos++
+		if size == 0 {
+			return pos, nil
+		}
+		pos += size
+	}
+}
@@ -0,0 +1,166 @@
+// Package media validates and prepares images attached to chirps. Uploads are
+// sniffed rather than trusted, decoded within size limits and re-encoded so no
+// metadata such as EXIF location data ever reaches storage.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	MaxUploadBytes = 5 << 20
+	MaxDimension   = 8192
+	MaxPixels      = 40_000_000
+	MaxGIFFrames   = 300
+	MaxGIFPixels   = 100_000_000
+	MaxDecodes     = 4
+	ThumbnailSize  = 320
+	MaxAttachments = 4
+	jpegQuality    = 90
+)
+
+const (
+	TypeJPEG = "image/jpeg"
+	TypePNG  = "image/png"
+	TypeGIF  = "image/gif"
+)
+
+var ErrUnsupportedType = errors.New("Only JPEG, PNG and GIF images are supported.")
+
+// decodeSlots bounds how many images are decoded at once, since each decode
+// can hold a few hundred megabytes.
+var decodeSlots = make(chan struct{}, MaxDecodes)
+
+// Image is an upload that is safe to store, along with its thumbnail.
+type Image struct {
+	ContentType          string
+	Data                 []byte
+	Width                int
+	Height               int
+	Thumbnail            []byte
+	ThumbnailContentType string
+}
+
+// Sniff detects the content type from the data itself, ignoring whatever the
+// client claimed.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case TypeJPEG, TypePNG, TypeGIF:
+		return contentType, nil
+	}
+	return "", ErrUnsupportedType
+}
+
+// Extension returns the file extension used when storing the content type.
+func Extension(contentType string) string {
+	switch contentType {
+	case TypeJPEG:
+		return ".jpg"
+	case TypePNG:
+		return ".png"
+	case TypeGIF:
+		return ".gif"
+	}
+	return ""
+}
+
+// ThumbnailContentType is JPEG for photos and PNG for everything else so
+// transparency survives.
+func ThumbnailContentType(contentType string) string {
+	if contentType == TypeJPEG {
+		return TypeJPEG
+	}
+	return TypePNG
+}
+
+// Process checks the dimensions before decoding so small files can't expand
+// into huge images, then re-encodes the image and renders its thumbnail.
+// GIFs are also limited in frame count and total frame area, since every
+// frame is decoded. JPEGs are rotated upright first since their EXIF
+// orientation is dropped. At most MaxDecodes calls decode at once; the rest
+// wait.
+func Process(data []byte) (Image, error) {
+	if len(data) > MaxUploadBytes {
+		return Image{}, fmt.Errorf("Image is too large. Max size is %v MB.", MaxUploadBytes>>20)
+	}
+	contentType, err := Sniff(data)
+	if err != nil {
+		return Image{}, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("Image could not be read.")
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension || config.Width*config.Height > MaxPixels {
+		return Image{}, fmt.Errorf("Image dimensions are too large. Max is %vx%v pixels.", MaxDimension, MaxDimension)
+	}
+	if contentType == TypeGIF {
+		frames, pixels, err := gifFrames(data)
+		if err != nil {
+			return Image{}, fmt.Errorf("Image could not be read.")
+		}
+		if frames > MaxGIFFrames {
+			return Image{}, fmt.Errorf("GIF has too many frames. Max is %v.", MaxGIFFrames)
+		}
+		if pixels > MaxGIFPixels {
+			return Image{}, fmt.Errorf("GIF is too large. Its frames can have at most %v pixels in total.", MaxGIFPixels)
+		}
+	}
+	decodeSlots <- struct{}{}
+	defer func() { <-decodeSlots }()
+	processed := Image{ContentType: contentType, ThumbnailContentType: ThumbnailContentType(contentType)}
+	var first image.Image
+	var out bytes.Buffer
+	switch contentType {
+	case TypeGIF:
+		animation, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("Image could not be read.")
+		}
+		// Comments and application extensions other than the loop count are
+		// not carried over by the encoder.
+		err = gif.EncodeAll(&out, animation)
+		if err != nil {
+			return Image{}, err
+		}
+		first = animation.Image[0]
+		processed.Width, processed.Height = animation.Config.Width, animation.Config.Height
+	default:
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("Image could not be read.")
+		}
+		if contentType == TypeJPEG {
+			decoded = orient(decoded, jpegOrientation(data))
+		}
+		err = encode(&out, decoded, contentType)
+		if err != nil {
+			return Image{}, err
+		}
+		first = decoded
+		processed.Width, processed.Height = decoded.Bounds().Dx(), decoded.Bounds().Dy()
+	}
+	processed.Data = out.Bytes()
+	var thumb bytes.Buffer
+	err = encode(&thumb, Thumbnail(first, ThumbnailSize), processed.ThumbnailContentType)
+	if err != nil {
+		return Image{}, err
+	}
+	processed.Thumbnail = thumb.Bytes()
+	return processed, nil
+}
+
+func encode(out *bytes.Buffer, img image.Image, contentType string) error {
+	if contentType == TypeJPEG {
+		return jpeg.Encode(out, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return png.Encode(out, img)
+}
@@ -0,0 +1,270 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+)
+
+func testImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+// withOrientation inserts an EXIF APP1 segment carrying the orientation tag
+// right after the JPEG's start of image marker.
+func withOrientation(jpegData []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, app1...)
+	return append(out, jpegData[2:]...)
+}
+
+func TestSniff(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, testImage(4, 4))
+	contentType, err := Sniff(buf.Bytes())
+	if err != nil || contentType != TypePNG {
+		t.Errorf("Expected %v, got %v, %v", TypePNG, contentType, err)
+		t.FailNow()
+	}
+	_, err = Sniff([]byte("<html><script>alert(1)</script></html>"))
+	if err != ErrUnsupportedType {
+		t.Errorf("Expected html to be rejected, got %v", err)
+		t.FailNow()
+	}
+}
+
+func TestProcessStripsExifAndRotates(t *testing.T) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, testImage(40, 20), nil)
+	data := withOrientation(buf.Bytes(), 6)
+	if jpegOrientation(data) != 6 {
+		t.Errorf("Expected orientation 6, got %v", jpegOrientation(data))
+		t.FailNow()
+	}
+	processed, err := Process(data)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		t.FailNow()
+	}
+	if bytes.Contains(processed.Data, []byte("Exif")) {
+		t.Errorf("Expected EXIF data to be stripped.")
+		t.FailNow()
+	}
+	if processed.Width != 20 || processed.Height != 40 {
+		t.Errorf("Expected a rotated 20x40 image, got %vx%v", processed.Width, processed.Height)
+		t.FailNow()
+	}
+	if processed.ContentType != TypeJPEG || processed.ThumbnailContentType != TypeJPEG {
+		t.Errorf("Unexpected content types: %v, %v", processed.ContentType, processed.ThumbnailContentType)
+		t.FailNow()
+	}
+}
+
+func TestProcessThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, testImage(1000, 500))
+	processed, err := Process(buf.Bytes())
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		t.FailNow()
+	}
+	thumb, err := png.Decode(bytes.NewReader(processed.Thumbnail))
+	if err != nil {
+		t.Errorf("Thumbnail is not a png: %v", err)
+		t.FailNow()
+	}
+	if thumb.Bounds().Dx() != ThumbnailSize || thumb.Bounds().Dy() != ThumbnailSize/2 {
+		t.Errorf("Unexpected thumbnail size %v", thumb.Bounds())
+		t.FailNow()
+	}
+}
+
+func TestProcessGIF(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	animation := &gif.GIF{
+		Image: []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 10, 10), palette), image.NewPaletted(image.Rect(0, 0, 10, 10), palette)},
+		Delay: []int{10, 10},
+	}
+	var buf bytes.Buffer
+	gif.EncodeAll(&buf, animation)
+	processed, err := Process(buf.Bytes())
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		t.FailNow()
+	}
+	decoded, err := gif.DecodeAll(bytes.NewReader(processed.Data))
+	if err != nil || len(decoded.Image) != 2 {
+		t.Errorf("Expected both frames to be kept: %v", err)
+		t.FailNow()
+	}
+	if processed.ThumbnailContentType != TypePNG {
+		t.Errorf("Expected a png thumbnail, got %v", processed.ThumbnailContentType)
+		t.FailNow()
+	}
+}
+
+// craftedGIF describes frames of the given size without real pixel data,
+// which is enough for the checks that run before decoding.
+func craftedGIF(frames, width, height int) []byte {
+	data := []byte("GIF89a")
+	data = binary.LittleEndian.AppendUint16(data, uint16(width))
+	data = binary.LittleEndian.AppendUint16(data, uint16(height))
+	data = append(data, 0x80, 0, 0, 0, 0, 0, 0xFF, 0xFF, 0xFF)
+	data = append(data, 0x21, 0xFF, 3, 'a', 'b', 'c', 0)
+	for i := 0; i < frames; i++ {
+		data = append(data, 0x2C, 0, 0, 0, 0)
+		data = binary.LittleEndian.AppendUint16(data, uint16(width))
+		data = binary.LittleEndian.AppendUint16(data, uint16(height))
+		data = append(data, 0x80, 0, 0, 0, 0xFF, 0xFF, 0xFF, 2, 2, 0x44, 0x01, 0)
+	}
+	return append(data, 0x3B)
+}
+
+func TestGIFFrames(t *testing.T) {
+	cases := []struct {
+		frames, width, height int
+	}{
+		{1, 1, 1},
+		{3, 10, 20},
+	}
+	for _, c := range cases {
+		frames, pixels, err := gifFrames(craftedGIF(c.frames, c.width, c.height))
+		if err != nil || frames != c.frames || pixels != c.frames*c.width*c.height {
+			t.Errorf("Expected %v frames of %vx%v, got %v frames, %v pixels, %v", c.frames, c.width, c.height, frames, pixels, err)
+			t.FailNow()
+		}
+	}
+
+	palette := color.Palette{color.Black, color.White}
+	animation := &gif.GIF{
+		Image: []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 10, 10), palette), image.NewPaletted(image.Rect(0, 0, 4, 5), color.Palette{color.White, color.Black})},
+		Delay: []int{10, 10},
+	}
+	var buf bytes.Buffer
+	gif.EncodeAll(&buf, animation)
+	frames, pixels, err := gifFrames(buf.Bytes())
+	if err != nil || frames != 2 || pixels != 120 {
+		t.Errorf("Expected 2 frames and 120 pixels, got %v, %v, %v", frames, pixels, err)
+		t.FailNow()
+	}
+
+	_, _, err = gifFrames([]byte("GIF89a\x01\x00\x01\x00\x00\x00\x00\x2C\x00"))
+	if err == nil {
+		t.Errorf("Expected a truncated descriptor to be rejected.")
+		t.FailNow()
+	}
+}
+
+func TestProcessRejectsGIFBombs(t *testing.T) {
+	cases := map[string][]byte{
+		"too many frames": craftedGIF(MaxGIFFrames+1, 1, 1),
+		"too many pixels": craftedGIF(3, 6000, 6000),
+		"truncated":       craftedGIF(1, 1, 1)[:13],
+	}
+	for name, data := range cases {
+		_, err := Process(data)
+		if err == nil {
+			t.Errorf("%v: expected the GIF to be rejected.", name)
+			t.FailNow()
+		}
+	}
+}
+
+func TestProcessCraftedGIF(t *testing.T) {
+	processed, err := Process(craftedGIF(MaxGIFFrames, 1, 1))
+	if err != nil || processed.Width != 1 || processed.Height != 1 {
+		t.Errorf("Expected %v frames to be allowed, got %+v, %v", MaxGIFFrames, processed, err)
+		t.FailNow()
+	}
+}
+
+func TestThumbnailReadsAnyImage(t *testing.T) {
+	nrgba := testImage(1000, 700)
+	rgba := image.NewRGBA(nrgba.Bounds())
+	for i := range rgba.Pix {
+		rgba.Pix[i] = nrgba.Pix[i]
+	}
+	fromNRGBA, fromRGBA := Thumbnail(nrgba, 100).(*image.NRGBA), Thumbnail(rgba, 100).(*image.NRGBA)
+	if !bytes.Equal(fromNRGBA.Pix, fromRGBA.Pix) {
+		t.Errorf("Expected the same thumbnail from NRGBA and RGBA sources.")
+		t.FailNow()
+	}
+	cropped := Thumbnail(nrgba.SubImage(image.Rect(500, 0, 1000, 700)), 100)
+	if cropped.Bounds().Dx() != 71 || cropped.Bounds().Dy() != 100 {
+		t.Errorf("Unexpected thumbnail size %v", cropped.Bounds())
+		t.FailNow()
+	}
+}
+
+func TestProcessRejectsHugeDimensions(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	var buf bytes.Buffer
+	gif.Encode(&buf, image.NewPaletted(image.Rect(0, 0, MaxDimension+1, 1), palette), nil)
+	_, err := Process(buf.Bytes())
+	if err == nil {
+		t.Errorf("Expected oversized image to be rejected.")
+		t.FailNow()
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		t.FailNow()
+	}
+	err = store.Put(ctx, "attachments/a.png", bytes.NewReader([]byte("data")))
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		t.FailNow()
+	}
+	blob, err := store.Open(ctx, "attachments/a.png")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		t.FailNow()
+	}
+	data, _ := io.ReadAll(blob)
+	blob.Close()
+	if string(data) != "data" {
+		t.Errorf("Unexpected blob contents %q", data)
+		t.FailNow()
+	}
+	if store.Delete(ctx, "attachments/a.png") != nil || store.Delete(ctx, "attachments/a.png") != nil {
+		t.Errorf("Expected deletes to succeed.")
+		t.FailNow()
+	}
+	_, err = store.Open(ctx, "attachments/a.png")
+	if err != ErrBlobNotFound {
+		t.Errorf("Expected ErrBlobNotFound, got %v", err)
+		t.FailNow()
+	}
+	if store.Put(ctx, "../escape", bytes.NewReader(nil)) == nil {
+		t.Errorf("Expected keys outside the store to be rejected.")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,60 @@
+package media
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Thumbnail scales the image down to fit within a size x size box, averaging
+// the source pixels behind each thumbnail pixel. Images that already fit are
+// returned unchanged. NRGBA images are read in place; anything else is
+// converted a band of rows at a time so a large photo is never copied whole.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+	outWidth, outHeight := size, height*size/width
+	if height > width {
+		outWidth, outHeight = width*size/height, size
+	}
+	outWidth, outHeight = max(outWidth, 1), max(outHeight, 1)
+
+	nrgba, inPlace := img.(*image.NRGBA)
+	var band *image.NRGBA
+	if !inPlace {
+		band = image.NewNRGBA(image.Rect(0, 0, width, height/outHeight+2))
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, outWidth, outHeight))
+	for outY := 0; outY < outHeight; outY++ {
+		y0, y1 := outY*height/outHeight, max((outY+1)*height/outHeight, outY*height/outHeight+1)
+		row := func(y int) []uint8 {
+			return nrgba.Pix[nrgba.PixOffset(bounds.Min.X, bounds.Min.Y+y):]
+		}
+		if !inPlace {
+			draw.Draw(band, image.Rect(0, 0, width, y1-y0), img, image.Pt(bounds.Min.X, bounds.Min.Y+y0), draw.Src)
+			row = func(y int) []uint8 {
+				return band.Pix[(y-y0)*band.Stride:]
+			}
+		}
+		for outX := 0; outX < outWidth; outX++ {
+			x0, x1 := outX*width/outWidth, max((outX+1)*width/outWidth, outX*width/outWidth+1)
+			var sum [4]int
+			for y := y0; y < y1; y++ {
+				pixels := row(y)
+				for x := x0; x < x1; x++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(pixels[x*4+c])
+					}
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			pixel := out.Pix[outY*out.Stride+outX*4:]
+			for c := 0; c < 4; c++ {
+				pixel[c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return out
+}
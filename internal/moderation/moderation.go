@@ -0,0 +1,96 @@
+// Package moderation holds the rules for user reports and the actions
+// moderators can take on them.
+package moderation
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	ReasonSpam           = "spam"
+	ReasonHarassment     = "harassment"
+	ReasonHate           = "hate"
+	ReasonViolence       = "violence"
+	ReasonSexual         = "sexual"
+	ReasonMisinformation = "misinformation"
+	ReasonOther          = "other"
+
+	// ActionDismiss closes the reports and leaves the chirp alone.
+	ActionDismiss = "dismiss"
+	// ActionHide keeps the chirp but removes it from every listing.
+	ActionHide   = "hide"
+	ActionDelete = "delete"
+	// ActionSuspend suspends the chirp's author.
+	ActionSuspend   = "suspend"
+	ActionUnsuspend = "unsuspend"
+
+	MaxDetailsLength = 500
+)
+
+var (
+	Reasons = []string{ReasonSpam, ReasonHarassment, ReasonHate, ReasonViolence, ReasonSexual, ReasonMisinformation, ReasonOther}
+	// Actions are the decisions a moderator can make about a reported chirp.
+	Actions = []string{ActionDismiss, ActionHide, ActionDelete, ActionSuspend}
+)
+
+func ValidateReason(reason string) error {
+	if !slices.Contains(Reasons, reason) {
+		return fmt.Errorf("Unknown reason %v. Use one of %v.", reason, Reasons)
+	}
+	return nil
+}
+
+func ValidateAction(action string) error {
+	if !slices.Contains(Actions, action) {
+		return fmt.Errorf("Unknown action %v. Use one of %v.", action, Actions)
+	}
+	return nil
+}
+
+// CountReasons tallies how often each reason was given for one chirp.
+func CountReasons(reasons []string) map[string]int {
+	counts := map[string]int{}
+	for _, reason := range reasons {
+		counts[reason]++
+	}
+	return counts
+}
+
+// SuspensionEnd turns a requested duration into the time a suspension ends.
+// A nil duration means the suspension is permanent and has no end.
+func SuspensionEnd(durationHours *int, now time.Time) (*time.Time, error) {
+	if durationHours == nil {
+		return nil, nil
+	}
+	if *durationHours < 1 {
+		return nil, fmt.Errorf("duration_hours must be at least 1.")
+	}
+	end := now.Add(time.Duration(*durationHours) * time.Hour)
+	return &end, nil
+}
+
+// IsSuspended reports whether a suspension is in force at now. Suspensions
+// without an end are permanent; expired ones simply stop applying.
+func IsSuspended(suspendedAt, suspendedUntil sql.NullTime, now time.Time) bool {
+	if !suspendedAt.Valid {
+		return false
+	}
+	return !suspendedUntil.Valid || suspendedUntil.Time.After(now)
+}
+
+// SuspensionMessage explains a suspension to the suspended user.
+func SuspensionMessage(suspendedUntil sql.NullTime, reason sql.NullString) string {
+	message := "Your account is suspended"
+	if suspendedUntil.Valid {
+		message += " until " + suspendedUntil.Time.UTC().Format(time.RFC3339)
+	} else {
+		message += " permanently"
+	}
+	if reason.Valid {
+		message += ": " + reason.String
+	}
+	return message + "."
+}
@@ -0,0 +1,87 @@
+package moderation
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestValidateReason(t *testing.T) {
+	for _, reason := range Reasons {
+		if err := ValidateReason(reason); err != nil {
+			t.Errorf("Expected %v to be valid: %v", reason, err)
+			t.FailNow()
+		}
+	}
+	if ValidateReason("boring") == nil {
+		t.Errorf("Expected unknown reason to be rejected.")
+		t.FailNow()
+	}
+	if ValidateAction("ban") == nil || ValidateAction(ActionHide) != nil {
+		t.Errorf("Unexpected action validation.")
+		t.FailNow()
+	}
+}
+
+func TestCountReasons(t *testing.T) {
+	counts := CountReasons([]string{ReasonSpam, ReasonHate, ReasonSpam})
+	if counts[ReasonSpam] != 2 || counts[ReasonHate] != 1 || len(counts) != 2 {
+		t.Errorf("Unexpected counts: %v", counts)
+		t.FailNow()
+	}
+}
+
+func TestSuspensionEnd(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	end, err := SuspensionEnd(nil, now)
+	if err != nil || end != nil {
+		t.Errorf("Expected a permanent suspension, got %v, %v", end, err)
+		t.FailNow()
+	}
+	hours := 48
+	end, err = SuspensionEnd(&hours, now)
+	if err != nil || !end.Equal(now.Add(48*time.Hour)) {
+		t.Errorf("Expected the suspension to end after 48 hours, got %v, %v", end, err)
+		t.FailNow()
+	}
+	hours = 0
+	if _, err = SuspensionEnd(&hours, now); err == nil {
+		t.Errorf("Expected a zero duration to be rejected.")
+		t.FailNow()
+	}
+}
+
+func TestIsSuspended(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	suspendedAt := sql.NullTime{Time: now.Add(-time.Hour), Valid: true}
+	if IsSuspended(sql.NullTime{}, sql.NullTime{}, now) {
+		t.Errorf("Expected a user without a suspension not to be suspended.")
+		t.FailNow()
+	}
+	if !IsSuspended(suspendedAt, sql.NullTime{}, now) {
+		t.Errorf("Expected a permanent suspension to apply.")
+		t.FailNow()
+	}
+	if !IsSuspended(suspendedAt, sql.NullTime{Time: now.Add(time.Hour), Valid: true}, now) {
+		t.Errorf("Expected a running suspension to apply.")
+		t.FailNow()
+	}
+	if IsSuspended(suspendedAt, sql.NullTime{Time: now.Add(-time.Minute), Valid: true}, now) {
+		t.Errorf("Expected an expired suspension not to apply.")
+		t.FailNow()
+	}
+}
+
+func TestSuspensionMessage(t *testing.T) {
+	until := sql.NullTime{Time: time.Date(2024, 10, 3, 12, 0, 0, 0, time.UTC), Valid: true}
+	message := SuspensionMessage(until, sql.NullString{String: "Spam", Valid: true})
+	if message != "Your account is suspended until 2024-10-03T12:00:00Z: Spam." {
+		t.Errorf("Unexpected message: %v", message)
+		t.FailNow()
+	}
+	message = SuspensionMessage(sql.NullTime{}, sql.NullString{})
+	if message != "Your account is suspended permanently." {
+		t.Errorf("Unexpected message: %v", message)
+		t.FailNow()
+	}
+}
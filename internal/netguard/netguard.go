@@ -0,0 +1,77 @@
+// Package netguard keeps outgoing requests to user-chosen URLs, such as link
+// previews and webhooks, from reaching the server's internal network.
+// Addresses are checked after DNS resolution, when the connection is made, so
+// a hostname can't resolve to a private address behind the check's back.
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var ErrBlockedAddress = errors.New("address is not allowed")
+
+// reservedPrefixes are non-public ranges that netip.Addr has no method for.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// IsPublicAddress reports whether addrPort may be connected to: a public
+// unicast address on port 80 or 443.
+func IsPublicAddress(addrPort netip.AddrPort) bool {
+	if addrPort.Port() != 80 && addrPort.Port() != 443 {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewTransport returns a transport that only dials addresses allow accepts,
+// failing with ErrBlockedAddress otherwise. allow defaults to
+// IsPublicAddress; tests loosen it to reach httptest servers. The transport
+// never uses a proxy, which would dial for it, and doesn't keep connections.
+func NewTransport(timeout time.Duration, allow func(netip.AddrPort) bool) *http.Transport {
+	if allow == nil {
+		allow = IsPublicAddress
+	}
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !allow(addrPort) {
+				return fmt.Errorf("%w: %v", ErrBlockedAddress, address)
+			}
+			return nil
+		},
+	}
+	return &http.Transport{
+		Proxy:                  nil,
+		DialContext:            dialer.DialContext,
+		TLSHandshakeTimeout:    timeout,
+		ResponseHeaderTimeout:  timeout,
+		MaxResponseHeaderBytes: 16 << 10,
+		DisableKeepAlives:      true,
+	}
+}
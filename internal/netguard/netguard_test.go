@@ -0,0 +1,28 @@
+package netguard
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.216.34:443":          true,
+		"93.184.216.34:8080":         false,
+		"127.0.0.1:80":               false,
+		"10.0.0.5:80":                false,
+		"169.254.169.254:80":         false,
+		"100.64.1.1:80":              false,
+		"0.0.0.0:80":                 false,
+		"[::1]:443":                  false,
+		"[::ffff:127.0.0.1]:80":      false,
+		"[fd00::1]:443":              false,
+		"[2606:4700:4700::1111]:443": true,
+	}
+	for address, expected := range cases {
+		if got := IsPublicAddress(netip.MustParseAddrPort(address)); got != expected {
+			t.Errorf("IsPublicAddress(%v) = %v, expected %v", address, got, expected)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Package notifications turns outbox events into per-user notifications,
+// honouring each user's preferences, and pushes them to live connections.
+package notifications
+
+import (
+	"Chirpy/internal/database"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypeMention   = "mention"
+	TypeReply     = "reply"
+	TypeFollow    = "follow"
+	TypeLike      = "like"
+	TypeChirpyRed = "chirpy_red"
+)
+
+// Users are identified by email, so a mention is "@" followed by an email
+// address, e.g. "hi @alice@example.com".
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.%+-]+@[\w-]+(?:\.[\w-]+)*\.[A-Za-z]{2,})`)
+
+// Mentions returns the distinct email addresses mentioned in body.
+func Mentions(body string) []string {
+	emails := []string{}
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			emails = append(emails, match[1])
+		}
+	}
+	return emails
+}
+
+// DefaultPreferences is used for users who never changed their preferences:
+// everything is on.
+func DefaultPreferences(userId uuid.UUID) database.NotificationPreference {
+	return database.NotificationPreference{
+		UserID:    userId,
+		Mentions:  true,
+		Replies:   true,
+		Follows:   true,
+		Likes:     true,
+		ChirpyRed: true,
+	}
+}
+
+func Allows(preferences database.NotificationPreference, notificationType string) bool {
+	switch notificationType {
+	case TypeMention:
+		return preferences.Mentions
+	case TypeReply:
+		return preferences.Replies
+	case TypeFollow:
+		return preferences.Follows
+	case TypeLike:
+		return preferences.Likes
+	case TypeChirpyRed:
+		return preferences.ChirpyRed
+	}
+	return false
+}
+
+type Response struct {
+	Id        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	ActorId   *uuid.UUID `json:"actor_id"`
+	ChirpId   *uuid.UUID `json:"chirp_id"`
+	Read      bool       `json:"read"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func NewResponse(notification database.Notification) Response {
+	resp := Response{
+		Id:        notification.ID,
+		Type:      notification.Type,
+		Read:      notification.ReadAt.Valid,
+		CreatedAt: notification.CreatedAt,
+	}
+	if notification.ActorID.Valid {
+		resp.ActorId = &notification.ActorID.UUID
+	}
+	if notification.ChirpID.Valid {
+		resp.ChirpId = &notification.ChirpID.UUID
+	}
+	if notification.ReadAt.Valid {
+		resp.ReadAt = &notification.ReadAt.Time
+	}
+	return resp
+}
@@ -0,0 +1,40 @@
+package notifications
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMentions(t *testing.T) {
+	emails := Mentions("@alice@example.com hi, cc @bob.smith@mail.example.org and @alice@example.com. Not me: carol@example.com")
+	expected := []string{"alice@example.com", "bob.smith@mail.example.org"}
+	if !slices.Equal(emails, expected) {
+		t.Errorf("Mentions returned %v, expected %v.", emails, expected)
+		t.FailNow()
+	}
+	if len(Mentions("no mentions @ all")) != 0 {
+		t.Error("Found a mention where there is none.")
+		t.FailNow()
+	}
+}
+
+func TestAllows(t *testing.T) {
+	preferences := DefaultPreferences(uuid.New())
+	for _, notificationType := range []string{TypeMention, TypeReply, TypeFollow, TypeLike, TypeChirpyRed} {
+		if !Allows(preferences, notificationType) {
+			t.Errorf("Default preferences don't allow %v.", notificationType)
+			t.FailNow()
+		}
+	}
+	preferences.Likes = false
+	if Allows(preferences, TypeLike) {
+		t.Error("Likes were allowed after being turned off.")
+		t.FailNow()
+	}
+	if Allows(preferences, "unknown") {
+		t.Error("Unknown notification type was allowed.")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,140 @@
+package notifications
+
+import (
+	"Chirpy/internal/database"
+	"Chirpy/internal/outbox"
+	"Chirpy/internal/stream"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// Sink creates notifications from outbox events. Notifications are unique per
+// event, recipient and type, so republished events don't notify twice.
+type Sink struct {
+	DB     *database.Queries
+	Stream *stream.Hub
+	Logger *log.Logger
+}
+
+func (sink Sink) Name() string {
+	return "notifications"
+}
+
+func (sink Sink) Publish(ctx context.Context, event outbox.Event) error {
+	switch event.Name {
+	case outbox.EventChirpCreated:
+		return sink.chirpCreated(ctx, event)
+	case outbox.EventChirpLiked:
+		payload := struct {
+			UserId   uuid.UUID `json:"user_id"`
+			ChirpId  uuid.UUID `json:"chirp_id"`
+			AuthorId uuid.UUID `json:"author_id"`
+		}{}
+		err := json.Unmarshal(event.Payload, &payload)
+		if err != nil {
+			return err
+		}
+		return sink.notify(ctx, event, payload.AuthorId, TypeLike, payload.UserId, payload.ChirpId)
+	case outbox.EventUserFollowed:
+		payload := struct {
+			FollowerId uuid.UUID `json:"follower_id"`
+			FolloweeId uuid.UUID `json:"followee_id"`
+		}{}
+		err := json.Unmarshal(event.Payload, &payload)
+		if err != nil {
+			return err
+		}
+		return sink.notify(ctx, event, payload.FolloweeId, TypeFollow, payload.FollowerId, uuid.Nil)
+	case outbox.EventUserUpgraded:
+		return sink.notify(ctx, event, event.UserID, TypeChirpyRed, uuid.Nil, uuid.Nil)
+	}
+	return nil
+}
+
+func (sink Sink) chirpCreated(ctx context.Context, event outbox.Event) error {
+	chirp := struct {
+		Id        uuid.UUID  `json:"id"`
+		Body      string     `json:"body"`
+		UserId    uuid.UUID  `json:"user_id"`
+		ReplyToId *uuid.UUID `json:"reply_to_id"`
+	}{}
+	err := json.Unmarshal(event.Payload, &chirp)
+	if err != nil {
+		return err
+	}
+	if chirp.ReplyToId != nil {
+		parent, err := sink.DB.GetOneChirp(ctx, *chirp.ReplyToId)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			err = sink.notify(ctx, event, parent.UserID, TypeReply, chirp.UserId, chirp.Id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	emails := Mentions(chirp.Body)
+	if len(emails) == 0 {
+		return nil
+	}
+	mentioned, err := sink.DB.GetUsersByEmails(ctx, emails)
+	if err != nil {
+		return err
+	}
+	for _, user := range mentioned {
+		err = sink.notify(ctx, event, user.ID, TypeMention, chirp.UserId, chirp.Id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notify stores a notification for recipient unless they caused it themselves,
+// turned that type off or are blocked from the actor either way, and pushes it
+// to their live connections.
+func (sink Sink) notify(ctx context.Context, event outbox.Event, recipientId uuid.UUID, notificationType string, actorId, chirpId uuid.UUID) error {
+	if recipientId == uuid.Nil || recipientId == actorId {
+		return nil
+	}
+	if actorId != uuid.Nil {
+		blocked, err := sink.DB.HasBlockBetween(ctx, database.HasBlockBetweenParams{BlockerID: actorId, BlockedID: recipientId})
+		if err != nil || blocked {
+			return err
+		}
+	}
+	preferences, err := sink.DB.GetNotificationPreferences(ctx, recipientId)
+	if err == sql.ErrNoRows {
+		preferences, err = DefaultPreferences(recipientId), nil
+	}
+	if err != nil {
+		return err
+	}
+	if !Allows(preferences, notificationType) {
+		return nil
+	}
+	notification, err := sink.DB.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:  recipientId,
+		EventID: event.ID,
+		Type:    notificationType,
+		ActorID: uuid.NullUUID{UUID: actorId, Valid: actorId != uuid.Nil},
+		ChirpID: uuid.NullUUID{UUID: chirpId, Valid: chirpId != uuid.Nil},
+	})
+	if err == sql.ErrNoRows {
+		// Already created when this event was published before.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = sink.Stream.Publish(stream.Event{Type: stream.EventNotification, RecipientID: recipientId}, NewResponse(notification))
+	if err != nil {
+		sink.Logger.Printf("Error publishing notification %v to live connections: %v", notification.ID, err)
+	}
+	return nil
+}
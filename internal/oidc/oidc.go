@@ -0,0 +1,253 @@
+// Package oidc implements the relying party side of OpenID Connect login
+// against a single configurable identity provider.
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	keysRefreshInterval = 5 * time.Minute
+	maxResponseSize     = 1 << 20
+)
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	HTTPClient   *http.Client
+}
+
+// Claims holds the parts of a verified ID token Chirpy cares about.
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// Provider lazily discovers the identity provider's endpoints and caches its
+// signing keys, so a provider that is down at startup doesn't stop Chirpy.
+type Provider struct {
+	cfg           Config
+	mu            sync.Mutex
+	discovery     *discoveryDocument
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewProvider(cfg Config) *Provider {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &Provider{cfg: cfg}
+}
+
+func (provider *Provider) Issuer() string {
+	return provider.cfg.Issuer
+}
+
+// AuthCodeURL returns the provider URL the user agent has to be sent to.
+func (provider *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	doc, err := provider.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", provider.cfg.ClientID)
+	params.Set("redirect_uri", provider.cfg.RedirectURL)
+	params.Set("scope", "openid email")
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+	separator := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return doc.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the claims of the
+// verified ID token.
+func (provider *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (Claims, error) {
+	doc, err := provider.discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	req, err := http.NewRequestWithContext(ctx, "POST", doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, fmt.Errorf("Unable to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(provider.cfg.ClientID), url.QueryEscape(provider.cfg.ClientSecret))
+	tokenResp := struct {
+		IDToken string `json:"id_token"`
+	}{}
+	err = provider.doJson(req, &tokenResp)
+	if err != nil {
+		return Claims{}, fmt.Errorf("Token exchange failed: %w", err)
+	}
+	if tokenResp.IDToken == "" {
+		return Claims{}, fmt.Errorf("Token response is missing the id_token.")
+	}
+	return provider.VerifyIDToken(ctx, tokenResp.IDToken, nonce)
+}
+
+func (provider *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (Claims, error) {
+	claims := idTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return provider.signingKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(provider.cfg.Issuer),
+		jwt.WithAudience(provider.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("Unable to verify id_token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return Claims{}, fmt.Errorf("id_token nonce does not match.")
+	}
+	if claims.Subject == "" {
+		return Claims{}, fmt.Errorf("id_token is missing the subject.")
+	}
+	return Claims{
+		Issuer:        provider.cfg.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}, nil
+}
+
+func (provider *Provider) discover(ctx context.Context) (*discoveryDocument, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if provider.discovery != nil {
+		return provider.discovery, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", provider.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to build discovery request: %w", err)
+	}
+	doc := discoveryDocument{}
+	err = provider.doJson(req, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != provider.cfg.Issuer {
+		return nil, fmt.Errorf("Discovered issuer %v does not match configured issuer %v", doc.Issuer, provider.cfg.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JwksURI == "" {
+		return nil, fmt.Errorf("Discovery document is missing required endpoints.")
+	}
+	provider.discovery = &doc
+	return provider.discovery, nil
+}
+
+// signingKey returns the key for kid, refetching the key set when the kid is
+// unknown so key rotation at the provider is picked up.
+func (provider *Provider) signingKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	doc, err := provider.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if key, ok := provider.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(provider.keysFetchedAt) < keysRefreshInterval && provider.keys != nil {
+		return nil, fmt.Errorf("Unknown signing key: %v", kid)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", doc.JwksURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to build jwks request: %w", err)
+	}
+	jwks := struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+	err = provider.doJson(req, &jwks)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch signing keys: %w", err)
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	provider.keys = keys
+	provider.keysFetchedAt = time.Now()
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("Unknown signing key: %v", kid)
+	}
+	return key, nil
+}
+
+func (provider *Provider) doJson(req *http.Request, target any) error {
+	resp, err := provider.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status %v from %v: %s", resp.StatusCode, req.URL, body)
+	}
+	return json.Unmarshal(body, target)
+}
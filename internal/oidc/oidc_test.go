@@ -0,0 +1,170 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "chirpy-test"
+	testClientSecret = "secret"
+	testCode         = "test-code"
+	testVerifier     = "test-verifier"
+	testNonce        = "test-nonce"
+)
+
+type mockProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+}
+
+// newMockProvider starts a local identity provider that serves discovery,
+// a key set and a token endpoint issuing ID tokens with the given claims.
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Couldn't generate rsa key: %v", err)
+	}
+	mock := &mockProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 mock.server.URL,
+			"authorization_endpoint": mock.server.URL + "/authorize",
+			"token_endpoint":         mock.server.URL + "/token",
+			"jwks_uri":               mock.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, _ := r.BasicAuth()
+		r.ParseForm()
+		if clientID != testClientID || secret != testClientSecret || r.PostForm.Get("code") != testCode || r.PostForm.Get("code_verifier") != testVerifier {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": mock.sign(t, mock.claims)})
+	})
+	mock.server = httptest.NewServer(mux)
+	mock.claims = jwt.MapClaims{
+		"iss":            mock.server.URL,
+		"aud":            testClientID,
+		"sub":            "external-user-1",
+		"email":          "user@example.com",
+		"email_verified": true,
+		"nonce":          testNonce,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+	t.Cleanup(mock.server.Close)
+	return mock
+}
+
+func (mock *mockProvider) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(mock.key)
+	if err != nil {
+		t.Fatalf("Couldn't sign id_token: %v", err)
+	}
+	return signed
+}
+
+func (mock *mockProvider) provider() *Provider {
+	return NewProvider(Config{
+		Issuer:       mock.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  "http://localhost:8080/api/auth/oidc/callback",
+	})
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	mock := newMockProvider(t)
+	authURL, err := mock.provider().AuthCodeURL(context.Background(), "state", testNonce, "challenge")
+	if err != nil {
+		t.Errorf("Couldn't build auth code url: %v", err)
+		t.FailNow()
+	}
+	parsed, _ := url.Parse(authURL)
+	query := parsed.Query()
+	if !strings.HasPrefix(authURL, mock.server.URL+"/authorize?") || query.Get("client_id") != testClientID || query.Get("state") != "state" || query.Get("code_challenge_method") != "S256" {
+		t.Errorf("Invalid auth code url: %v", authURL)
+		t.FailNow()
+	}
+}
+
+func TestExchange(t *testing.T) {
+	mock := newMockProvider(t)
+	claims, err := mock.provider().Exchange(context.Background(), testCode, testVerifier, testNonce)
+	if err != nil {
+		t.Errorf("Couldn't exchange code: %v", err)
+		t.FailNow()
+	}
+	if claims.Subject != "external-user-1" || claims.Email != "user@example.com" || !claims.EmailVerified || claims.Issuer != mock.server.URL {
+		t.Errorf("Invalid claims returned by Exchange: %+v", claims)
+		t.FailNow()
+	}
+	_, err = mock.provider().Exchange(context.Background(), "wrong-code", testVerifier, testNonce)
+	if err == nil {
+		t.Error("Exchange succeeded with an invalid code.")
+		t.FailNow()
+	}
+}
+
+func TestVerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.provider()
+	cases := map[string]jwt.MapClaims{
+		"wrong audience": {"aud": "another-client"},
+		"wrong issuer":   {"iss": "https://evil.example.com"},
+		"expired":        {"exp": time.Now().Add(-time.Hour).Unix()},
+		"wrong nonce":    {"nonce": "replayed"},
+	}
+	for name, override := range cases {
+		claims := jwt.MapClaims{}
+		for k, v := range mock.claims {
+			claims[k] = v
+		}
+		for k, v := range override {
+			claims[k] = v
+		}
+		_, err := provider.VerifyIDToken(context.Background(), mock.sign(t, claims), testNonce)
+		if err == nil {
+			t.Errorf("id_token with %v was accepted.", name)
+			t.FailNow()
+		}
+	}
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, mock.claims)
+	forged.Header["kid"] = "test-key"
+	signed, _ := forged.SignedString(otherKey)
+	_, err := provider.VerifyIDToken(context.Background(), signed, testNonce)
+	if err == nil {
+		t.Error("id_token signed with an unknown key was accepted.")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"Chirpy/internal/database"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	dispatchBatchSize = 100
+	retryBase         = 5 * time.Second
+	retryMax          = time.Hour
+)
+
+type Dispatcher struct {
+	db       *database.Queries
+	sinks    []Sink
+	logger   *log.Logger
+	interval time.Duration
+	wake     chan struct{}
+}
+
+func NewDispatcher(db *database.Queries, logger *log.Logger, interval time.Duration, sinks ...Sink) *Dispatcher {
+	return &Dispatcher{
+		db:       db,
+		sinks:    sinks,
+		logger:   logger,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Start polls the outbox every interval in its own goroutine until ctx is
+// cancelled. Notify makes it poll straight away.
+func (dispatcher *Dispatcher) Start(ctx context.Context) {
+	go dispatcher.loop(ctx)
+}
+
+// Notify wakes the dispatcher after a transaction that recorded events has
+// committed. It never blocks and is safe to call on a nil Dispatcher.
+func (dispatcher *Dispatcher) Notify() {
+	if dispatcher == nil {
+		return
+	}
+	select {
+	case dispatcher.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (dispatcher *Dispatcher) loop(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.interval)
+	defer ticker.Stop()
+	for {
+		err := dispatcher.DispatchPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			dispatcher.logger.Printf("Error dispatching outbox events: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-dispatcher.wake:
+		}
+	}
+}
+
+// DispatchPending publishes every due event in the order it was recorded.
+// Claimed events are leased for a minute so a crash only delays them.
+func (dispatcher *Dispatcher) DispatchPending(ctx context.Context) error {
+	for {
+		rows, err := dispatcher.db.ClaimOutboxEvents(ctx, dispatchBatchSize)
+		if err != nil {
+			return err
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i].CreatedAt.Before(rows[j].CreatedAt) })
+		for _, row := range rows {
+			err = dispatcher.dispatch(ctx, row)
+			if err != nil {
+				return err
+			}
+		}
+		if len(rows) < dispatchBatchSize {
+			return nil
+		}
+	}
+}
+
+func (dispatcher *Dispatcher) dispatch(ctx context.Context, row database.OutboxEvent) error {
+	publishErr := publish(ctx, dispatcher.sinks, newEvent(row))
+	if publishErr == nil {
+		return dispatcher.db.MarkOutboxEventPublished(ctx, row.ID)
+	}
+	attempts := int(row.Attempts) + 1
+	dispatcher.logger.Printf("Error publishing outbox event %v (%v), attempt %v: %v", row.ID, row.Event, attempts, publishErr)
+	return dispatcher.db.MarkOutboxEventFailed(ctx, database.MarkOutboxEventFailedParams{
+		ID:            row.ID,
+		LastError:     sql.NullString{String: publishErr.Error(), Valid: true},
+		NextAttemptAt: time.Now().Add(retryDelay(attempts)),
+	})
+}
+
+// publish hands event to every sink, even after one fails, so a broken sink
+// doesn't hold up the others any longer than the retry.
+func publish(ctx context.Context, sinks []Sink, event Event) error {
+	var firstErr error
+	for _, sink := range sinks {
+		err := sink.Publish(ctx, event)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("Sink %v: %w", sink.Name(), err)
+		}
+	}
+	return firstErr
+}
+
+func retryDelay(attempts int) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMax {
+			return retryMax
+		}
+	}
+	return delay
+}
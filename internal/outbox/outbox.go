@@ -0,0 +1,62 @@
+// Package outbox records domain events in the same transaction as the change
+// that caused them and publishes them to sinks from a background dispatcher.
+// Delivery is at least once, so sinks must tolerate seeing an event ID twice.
+package outbox
+
+import (
+	"Chirpy/internal/database"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventChirpCreated   = "chirp.created"
+	EventChirpDeleted   = "chirp.deleted"
+	EventChirpLiked     = "chirp.liked"
+	EventUserFollowed   = "user.followed"
+	EventUserUpgraded   = "user.upgraded"
+	EventUserDowngraded = "user.downgraded"
+)
+
+type Event struct {
+	ID        uuid.UUID
+	Name      string
+	UserID    uuid.UUID
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
+
+// Sink receives published events. Publish may be called again for an event it
+// already accepted, for example when another sink failed.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event Event) error
+}
+
+// Record writes event to the outbox. qtx should be bound to the transaction
+// making the change so the event exists if and only if the change commits.
+func Record(ctx context.Context, qtx *database.Queries, event string, userId uuid.UUID, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = qtx.CreateOutboxEvent(ctx, database.CreateOutboxEventParams{
+		Event:   event,
+		UserID:  uuid.NullUUID{UUID: userId, Valid: userId != uuid.Nil},
+		Payload: payload,
+	})
+	return err
+}
+
+func newEvent(row database.OutboxEvent) Event {
+	return Event{
+		ID:        row.ID,
+		Name:      row.Event,
+		UserID:    row.UserID.UUID,
+		Payload:   row.Payload,
+		CreatedAt: row.CreatedAt,
+	}
+}
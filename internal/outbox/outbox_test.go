@@ -0,0 +1,86 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type recordingSink struct {
+	name      string
+	err       error
+	published []Event
+}
+
+func (sink *recordingSink) Name() string {
+	return sink.name
+}
+
+func (sink *recordingSink) Publish(ctx context.Context, event Event) error {
+	sink.published = append(sink.published, event)
+	return sink.err
+}
+
+func TestPublishReachesEverySink(t *testing.T) {
+	failing := &recordingSink{name: "failing", err: errors.New("unavailable")}
+	working := &recordingSink{name: "working"}
+	event := Event{ID: uuid.New(), Name: EventChirpCreated}
+	err := publish(context.Background(), []Sink{failing, working}, event)
+	if err == nil || !strings.Contains(err.Error(), "failing") {
+		t.Errorf("Expected error naming the failing sink, got %v", err)
+		t.FailNow()
+	}
+	if len(working.published) != 1 || working.published[0].ID != event.ID {
+		t.Error("Event was not published to the sink after the failing one.")
+		t.FailNow()
+	}
+	err = publish(context.Background(), []Sink{working}, event)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		t.FailNow()
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  5 * time.Second,
+		2:  10 * time.Second,
+		4:  40 * time.Second,
+		30: time.Hour,
+	}
+	for attempts, expected := range cases {
+		if got := retryDelay(attempts); got != expected {
+			t.Errorf("retryDelay(%v) = %v, expected %v.", attempts, got, expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestNotifyNeverBlocks(t *testing.T) {
+	var nilDispatcher *Dispatcher
+	nilDispatcher.Notify()
+	dispatcher := NewDispatcher(nil, log.New(&bytes.Buffer{}, "", 0), time.Minute)
+	dispatcher.Notify()
+	dispatcher.Notify()
+	if len(dispatcher.wake) != 1 {
+		t.Errorf("Expected one pending wake up, got %v.", len(dispatcher.wake))
+		t.FailNow()
+	}
+}
+
+func TestLogSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sink := LogSink{Logger: log.New(buf, "", 0)}
+	event := Event{ID: uuid.New(), Name: EventUserUpgraded, Payload: []byte(`{"plan":"monthly"}`)}
+	err := sink.Publish(context.Background(), event)
+	if err != nil || !strings.Contains(buf.String(), event.ID.String()) || !strings.Contains(buf.String(), "monthly") {
+		t.Errorf("Unexpected log output %q: %v", buf.String(), err)
+		t.FailNow()
+	}
+}
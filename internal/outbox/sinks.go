@@ -0,0 +1,21 @@
+package outbox
+
+import (
+	"context"
+	"log"
+)
+
+// LogSink writes every event to a logger. It is useful as an audit trail and
+// while developing new sinks.
+type LogSink struct {
+	Logger *log.Logger
+}
+
+func (sink LogSink) Name() string {
+	return "log"
+}
+
+func (sink LogSink) Publish(ctx context.Context, event Event) error {
+	sink.Logger.Printf("Outbox event %v %v user=%v payload=%s", event.ID, event.Name, event.UserID, event.Payload)
+	return nil
+}
@@ -0,0 +1,65 @@
+// Package polls holds the rules for polls attached to chirps: how many
+// options they have, how long they stay open and who may see the results.
+package polls
+
+import (
+	"Chirpy/internal/validation"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	MinOptions      = 2
+	MaxOptions      = 4
+	MaxOptionLength = 25
+
+	MinDuration = 5 * time.Minute
+	MaxDuration = 7 * 24 * time.Hour
+)
+
+// ValidateOptions trims the options and checks their number, length and that
+// no two are the same, ignoring case.
+func ValidateOptions(options []string) ([]string, error) {
+	if len(options) < MinOptions || len(options) > MaxOptions {
+		return nil, fmt.Errorf("A poll needs between %v and %v options.", MinOptions, MaxOptions)
+	}
+	cleaned := make([]string, 0, len(options))
+	seen := map[string]bool{}
+	for _, option := range options {
+		option = validation.Clean(option)
+		if option == "" {
+			return nil, fmt.Errorf("Poll options cannot be empty.")
+		}
+		if validation.Length(option) > MaxOptionLength {
+			return nil, fmt.Errorf("Poll options can be at most %v characters.", MaxOptionLength)
+		}
+		if seen[strings.ToLower(option)] {
+			return nil, fmt.Errorf("Poll options must be different.")
+		}
+		seen[strings.ToLower(option)] = true
+		cleaned = append(cleaned, option)
+	}
+	return cleaned, nil
+}
+
+// ClosingTime returns when a poll that opens at opensAt and runs for
+// durationMinutes closes.
+func ClosingTime(opensAt time.Time, durationMinutes int) (time.Time, error) {
+	duration := time.Duration(durationMinutes) * time.Minute
+	if duration < MinDuration || duration > MaxDuration {
+		return time.Time{}, fmt.Errorf("Poll duration must be between %v and %v minutes.", int(MinDuration.Minutes()), int(MaxDuration.Minutes()))
+	}
+	return opensAt.Add(duration), nil
+}
+
+// IsClosed reports whether voting has ended.
+func IsClosed(closesAt, now time.Time) bool {
+	return !now.Before(closesAt)
+}
+
+// CanSeeResults reports whether a viewer may see the tallies: once they have
+// voted, if they wrote the poll, or once it has closed.
+func CanSeeResults(hasVoted, isAuthor bool, closesAt, now time.Time) bool {
+	return hasVoted || isAuthor || IsClosed(closesAt, now)
+}
@@ -0,0 +1,55 @@
+package polls
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateOptions(t *testing.T) {
+	options, err := ValidateOptions([]string{" Yes ", "No"})
+	if err != nil || options[0] != "Yes" || options[1] != "No" {
+		t.Errorf("Unexpected result %v, %v", options, err)
+		t.FailNow()
+	}
+	invalid := [][]string{
+		{"Only one"},
+		{"a", "b", "c", "d", "e"},
+		{"a", "  "},
+		{"Yes", "yes"},
+		{"a", "this option is far too long to fit"},
+	}
+	for _, options := range invalid {
+		if _, err := ValidateOptions(options); err == nil {
+			t.Errorf("Expected %q to be rejected.", options)
+			t.FailNow()
+		}
+	}
+}
+
+func TestClosingTime(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	closesAt, err := ClosingTime(now, 60)
+	if err != nil || !closesAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Unexpected closing time %v, %v", closesAt, err)
+		t.FailNow()
+	}
+	for _, minutes := range []int{0, 4, 7*24*60 + 1} {
+		if _, err := ClosingTime(now, minutes); err == nil {
+			t.Errorf("Expected %v minutes to be rejected.", minutes)
+			t.FailNow()
+		}
+	}
+}
+
+func TestCanSeeResults(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	open, closed := now.Add(time.Hour), now
+	if CanSeeResults(false, false, open, now) {
+		t.Errorf("Expected results to be hidden before voting.")
+		t.FailNow()
+	}
+	if !CanSeeResults(true, false, open, now) || !CanSeeResults(false, true, open, now) || !CanSeeResults(false, false, closed, now) {
+		t.Errorf("Expected results to be visible.")
+		t.FailNow()
+	}
+}
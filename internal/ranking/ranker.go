@@ -0,0 +1,65 @@
+package ranking
+
+import (
+	"Chirpy/internal/database"
+	"context"
+	"database/sql"
+)
+
+type Ranker struct {
+	DB    *database.Queries
+	SQLDB *sql.DB
+}
+
+// Refresh recomputes the explore rankings and swaps them in within one
+// transaction, so readers see either the old ranking or the new one. An
+// advisory lock taken first makes concurrent refreshes, such as one per
+// server instance, run one after the other instead of interleaving their
+// inserts. Ages are measured from the database's clock, the one that stamped
+// the chirps.
+func (ranker *Ranker) Refresh(ctx context.Context) error {
+	tx, err := ranker.SQLDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := ranker.DB.WithTx(tx)
+	err = qtx.LockChirpRankings(ctx)
+	if err != nil {
+		return err
+	}
+	now, err := qtx.GetRankingTime(ctx)
+	if err != nil {
+		return err
+	}
+	rows, err := qtx.GetChirpEngagementSince(ctx, now.Add(-Window))
+	if err != nil {
+		return err
+	}
+	engagements := make([]Engagement, 0, len(rows))
+	for _, row := range rows {
+		engagements = append(engagements, Engagement{
+			ChirpID:  row.ID,
+			PostedAt: row.PostedAt,
+			Likes:    row.Likes,
+			Replies:  row.Replies,
+		})
+	}
+	ranked := Rank(engagements, now)
+	err = qtx.DeleteChirpRankings(ctx)
+	if err != nil {
+		return err
+	}
+	for i, chirp := range ranked {
+		err = qtx.CreateChirpRanking(ctx, database.CreateChirpRankingParams{
+			Rank:     int32(i + 1),
+			ChirpID:  chirp.ChirpID,
+			Score:    chirp.Score,
+			RankedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
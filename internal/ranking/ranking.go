@@ -0,0 +1,79 @@
+// Package ranking orders recent chirps for the explore feed by engagement
+// that decays with age, so a burst of likes lifts a chirp for a while rather
+// than forever.
+package ranking
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LikeWeight  = 1.0
+	ReplyWeight = 2.0
+
+	// Gravity is how quickly scores fall with age. An hour-old chirp needs
+	// about 1.8 times the engagement of a new one to rank alongside it.
+	Gravity = 1.5
+	// AgeOffset keeps brand new chirps from dividing by nearly zero.
+	AgeOffset = 2 * time.Hour
+
+	// Window is how far back chirps are considered and MaxRanked how many
+	// are kept.
+	Window    = 3 * 24 * time.Hour
+	MaxRanked = 1000
+)
+
+// Engagement is what a chirp is scored on.
+// TODO: count rechirps here, with their own weight, once Chirpy has them.
+type Engagement struct {
+	ChirpID  uuid.UUID
+	PostedAt time.Time
+	Likes    int64
+	Replies  int64
+}
+
+type Ranked struct {
+	ChirpID uuid.UUID
+	Score   float64
+}
+
+// Score is the weighted engagement divided by (age + AgeOffset)^Gravity, with
+// age in hours.
+func Score(engagement Engagement, now time.Time) float64 {
+	age := max(now.Sub(engagement.PostedAt), 0)
+	points := LikeWeight*float64(engagement.Likes) + ReplyWeight*float64(engagement.Replies)
+	return points / math.Pow((age+AgeOffset).Hours(), Gravity)
+}
+
+// Rank scores the chirps and returns at most MaxRanked of them, best first.
+// Chirps without any engagement are left out. Ties go to the newer chirp.
+func Rank(engagements []Engagement, now time.Time) []Ranked {
+	sorted := make([]Engagement, 0, len(engagements))
+	scores := map[uuid.UUID]float64{}
+	for _, engagement := range engagements {
+		if engagement.Likes == 0 && engagement.Replies == 0 {
+			continue
+		}
+		sorted = append(sorted, engagement)
+		scores[engagement.ChirpID] = Score(engagement, now)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		scoreI, scoreJ := scores[sorted[i].ChirpID], scores[sorted[j].ChirpID]
+		if scoreI != scoreJ {
+			return scoreI > scoreJ
+		}
+		return sorted[i].PostedAt.After(sorted[j].PostedAt)
+	})
+	if len(sorted) > MaxRanked {
+		sorted = sorted[:MaxRanked]
+	}
+	ranked := make([]Ranked, 0, len(sorted))
+	for _, engagement := range sorted {
+		ranked = append(ranked, Ranked{ChirpID: engagement.ChirpID, Score: scores[engagement.ChirpID]})
+	}
+	return ranked
+}
@@ -0,0 +1,52 @@
+package ranking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestScoreDecaysWithAge(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	fresh := Score(Engagement{PostedAt: now, Likes: 10}, now)
+	old := Score(Engagement{PostedAt: now.Add(-24 * time.Hour), Likes: 10}, now)
+	if fresh <= old {
+		t.Errorf("Expected a fresh chirp (%v) to outscore an old one (%v).", fresh, old)
+		t.FailNow()
+	}
+	liked := Score(Engagement{PostedAt: now, Likes: 2}, now)
+	replied := Score(Engagement{PostedAt: now, Replies: 1}, now)
+	if liked != replied {
+		t.Errorf("Expected a reply to count as two likes, got %v and %v.", replied, liked)
+		t.FailNow()
+	}
+	future := Score(Engagement{PostedAt: now.Add(time.Hour), Likes: 10}, now)
+	if future != fresh {
+		t.Errorf("Expected clock skew to count as age zero, got %v.", future)
+		t.FailNow()
+	}
+}
+
+func TestRank(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	quiet := Engagement{ChirpID: uuid.New(), PostedAt: now}
+	popularOld := Engagement{ChirpID: uuid.New(), PostedAt: now.Add(-48 * time.Hour), Likes: 50}
+	risingNew := Engagement{ChirpID: uuid.New(), PostedAt: now.Add(-time.Hour), Likes: 10, Replies: 2}
+	oneLike := Engagement{ChirpID: uuid.New(), PostedAt: now.Add(-2 * time.Hour), Likes: 1}
+	ranked := Rank([]Engagement{quiet, oneLike, popularOld, risingNew}, now)
+	if len(ranked) != 3 {
+		t.Errorf("Expected chirps without engagement to be left out, got %v.", ranked)
+		t.FailNow()
+	}
+	if ranked[0].ChirpID != risingNew.ChirpID || ranked[1].ChirpID != popularOld.ChirpID || ranked[2].ChirpID != oneLike.ChirpID {
+		t.Errorf("Unexpected order %v.", ranked)
+		t.FailNow()
+	}
+	for i := 1; i < len(ranked); i++ {
+		if ranked[i].Score > ranked[i-1].Score {
+			t.Errorf("Expected scores in descending order, got %v.", ranked)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Package ratelimit provides an in-memory fixed window rate limiter.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type window struct {
+	start time.Time
+	count int
+}
+
+type Limiter struct {
+	mu      sync.Mutex
+	period  time.Duration
+	windows map[string]*window
+	now     func() time.Time
+}
+
+func New(period time.Duration) *Limiter {
+	return &Limiter{period: period, windows: map[string]*window{}, now: time.Now}
+}
+
+// Allow counts a request for key and reports whether it is within limit for
+// the current window.
+func (limiter *Limiter) Allow(key string, limit int) bool {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	now := limiter.now()
+	w, ok := limiter.windows[key]
+	if !ok || now.Sub(w.start) >= limiter.period {
+		w = &window{start: now}
+		limiter.windows[key] = w
+	}
+	if w.count >= limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// Prune drops windows that have ended so idle keys don't accumulate.
+func (limiter *Limiter) Prune() {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	now := limiter.now()
+	for key, w := range limiter.windows {
+		if now.Sub(w.start) >= limiter.period {
+			delete(limiter.windows, key)
+		}
+	}
+}
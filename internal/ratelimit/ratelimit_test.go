@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Now()
+	limiter := New(time.Minute)
+	limiter.now = func() time.Time { return now }
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow("user", 3) {
+			t.Errorf("Request %v was rejected within the limit.", i+1)
+			t.FailNow()
+		}
+	}
+	if limiter.Allow("user", 3) {
+		t.Error("Request over the limit was allowed.")
+		t.FailNow()
+	}
+	if !limiter.Allow("other", 3) {
+		t.Error("Limit was shared between keys.")
+		t.FailNow()
+	}
+	now = now.Add(time.Minute)
+	if !limiter.Allow("user", 3) {
+		t.Error("Request was rejected after the window ended.")
+		t.FailNow()
+	}
+}
+
+func TestLimiterPrune(t *testing.T) {
+	now := time.Now()
+	limiter := New(time.Minute)
+	limiter.now = func() time.Time { return now }
+	limiter.Allow("user", 1)
+	now = now.Add(2 * time.Minute)
+	limiter.Prune()
+	if len(limiter.windows) != 0 {
+		t.Errorf("Expected expired windows to be pruned, %v left.", len(limiter.windows))
+		t.FailNow()
+	}
+}
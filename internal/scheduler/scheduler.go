@@ -0,0 +1,52 @@
+// Package scheduler runs periodic background jobs for the server.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	logger *log.Logger
+	jobs   []job
+}
+
+func New(logger *log.Logger) *Scheduler {
+	return &Scheduler{logger: logger}
+}
+
+// Add registers a job that runs every interval once the scheduler is started.
+func (scheduler *Scheduler) Add(name string, interval time.Duration, run func(ctx context.Context) error) {
+	scheduler.jobs = append(scheduler.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start runs every job immediately and then on its interval in its own
+// goroutine until ctx is cancelled. Errors are logged and the job keeps running.
+func (scheduler *Scheduler) Start(ctx context.Context) {
+	for _, j := range scheduler.jobs {
+		go scheduler.loop(ctx, j)
+	}
+}
+
+func (scheduler *Scheduler) loop(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		err := j.run(ctx)
+		if err != nil && ctx.Err() == nil {
+			scheduler.logger.Printf("Error running scheduled job %v: %v", j.name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
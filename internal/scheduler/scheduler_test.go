@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsJobsUntilCancelled(t *testing.T) {
+	var runs atomic.Int32
+	var failures atomic.Int32
+	scheduler := New(log.New(io.Discard, "", 0))
+	scheduler.Add("counter", 10*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	scheduler.Add("failing", 10*time.Millisecond, func(ctx context.Context) error {
+		failures.Add(1)
+		return errors.New("job failed")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler.Start(ctx)
+	time.Sleep(55 * time.Millisecond)
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	stoppedAt := runs.Load()
+	if stoppedAt < 3 {
+		t.Errorf("Job ran %v times, expected at least 3.", stoppedAt)
+		t.FailNow()
+	}
+	if failures.Load() < 3 {
+		t.Errorf("Failing job stopped being scheduled after %v runs.", failures.Load())
+		t.FailNow()
+	}
+	time.Sleep(30 * time.Millisecond)
+	if runs.Load() != stoppedAt {
+		t.Error("Job kept running after the context was cancelled.")
+		t.FailNow()
+	}
+}
+
+func TestSchedulerRunsJobsOnStart(t *testing.T) {
+	ran := make(chan struct{}, 1)
+	scheduler := New(log.New(io.Discard, "", 0))
+	scheduler.Add("hourly", time.Hour, func(ctx context.Context) error {
+		ran <- struct{}{}
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler.Start(ctx)
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Error("Job did not run when the scheduler started.")
+		t.FailNow()
+	}
+}
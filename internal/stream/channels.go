@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ChannelTimeline      = "timeline"
+	ChannelAuthor        = "author"
+	ChannelHashtag       = "hashtag"
+	ChannelNotifications = "notifications"
+)
+
+var (
+	hashtagPattern = regexp.MustCompile(`#([\p{L}\p{N}_]+)`)
+	hashtagName    = regexp.MustCompile(`^[\p{L}\p{N}_]+$`)
+)
+
+// Hashtags returns the distinct lowercased hashtags in body, without the #.
+func Hashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, match := range hashtagPattern.FindAllStringSubmatch(body, -1) {
+		tag := strings.ToLower(match[1])
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Channel is something a live client can subscribe to: "timeline",
+// "notifications", "author:<user id>" or "hashtag:<tag>".
+type Channel struct {
+	Kind     string
+	AuthorID uuid.UUID
+	Hashtag  string
+}
+
+func ParseChannel(name string) (Channel, error) {
+	kind, value, _ := strings.Cut(name, ":")
+	switch kind {
+	case ChannelTimeline, ChannelNotifications:
+		if value != "" {
+			return Channel{}, fmt.Errorf("Channel %v takes no argument.", kind)
+		}
+		return Channel{Kind: kind}, nil
+	case ChannelAuthor:
+		authorId, err := uuid.Parse(value)
+		if err != nil {
+			return Channel{}, fmt.Errorf("Invalid author id in channel %v.", name)
+		}
+		return Channel{Kind: kind, AuthorID: authorId}, nil
+	case ChannelHashtag:
+		tag := strings.ToLower(strings.TrimPrefix(value, "#"))
+		if !hashtagName.MatchString(tag) {
+			return Channel{}, fmt.Errorf("Invalid hashtag in channel %v.", name)
+		}
+		return Channel{Kind: kind, Hashtag: tag}, nil
+	}
+	return Channel{}, fmt.Errorf("Unknown channel %v.", name)
+}
+
+func (channel Channel) String() string {
+	switch channel.Kind {
+	case ChannelAuthor:
+		return ChannelAuthor + ":" + channel.AuthorID.String()
+	case ChannelHashtag:
+		return ChannelHashtag + ":" + channel.Hashtag
+	}
+	return channel.Kind
+}
+
+// Matches reports whether event belongs on channel for userId. following is
+// the set of users userId follows and is only used by the timeline.
+func (channel Channel) Matches(event Event, userId uuid.UUID, following map[uuid.UUID]bool) bool {
+	if channel.Kind == ChannelNotifications {
+		return event.Type == EventNotification && event.RecipientID == userId
+	}
+	if !event.IsChirpEvent() {
+		return false
+	}
+	switch channel.Kind {
+	case ChannelTimeline:
+		return event.AuthorID == userId || following[event.AuthorID]
+	case ChannelAuthor:
+		return event.AuthorID == channel.AuthorID
+	case ChannelHashtag:
+		for _, tag := range event.Hashtags {
+			if tag == channel.Hashtag {
+				return true
+			}
+		}
+	}
+	return false
+}
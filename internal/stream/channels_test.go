@@ -0,0 +1,75 @@
+package stream
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHashtags(t *testing.T) {
+	tags := Hashtags("Loving #Go and #go_lang, also #Go again and #日本")
+	expected := []string{"go", "go_lang", "日本"}
+	if !slices.Equal(tags, expected) {
+		t.Errorf("Hashtags returned %v, expected %v.", tags, expected)
+		t.FailNow()
+	}
+}
+
+func TestParseChannel(t *testing.T) {
+	authorId := uuid.New()
+	valid := map[string]Channel{
+		"timeline":                    {Kind: ChannelTimeline},
+		"notifications":               {Kind: ChannelNotifications},
+		"author:" + authorId.String(): {Kind: ChannelAuthor, AuthorID: authorId},
+		"hashtag:#GoLang":             {Kind: ChannelHashtag, Hashtag: "golang"},
+	}
+	for name, expected := range valid {
+		channel, err := ParseChannel(name)
+		if err != nil || channel != expected {
+			t.Errorf("ParseChannel(%v) = %+v, %v", name, channel, err)
+			t.FailNow()
+		}
+	}
+	for _, name := range []string{"", "everything", "author:nope", "hashtag:", "hashtag:two words", "timeline:x"} {
+		if _, err := ParseChannel(name); err == nil {
+			t.Errorf("ParseChannel(%q) should have failed.", name)
+			t.FailNow()
+		}
+	}
+}
+
+func TestChannelMatches(t *testing.T) {
+	userId := uuid.New()
+	followed := uuid.New()
+	stranger := uuid.New()
+	following := map[uuid.UUID]bool{followed: true}
+	timeline := Channel{Kind: ChannelTimeline}
+	if !timeline.Matches(Event{Type: EventChirpCreated, AuthorID: followed}, userId, following) {
+		t.Error("Timeline is missing a followed author's chirp.")
+		t.FailNow()
+	}
+	if timeline.Matches(Event{Type: EventChirpCreated, AuthorID: stranger}, userId, following) {
+		t.Error("Timeline included a chirp from an author the user doesn't follow.")
+		t.FailNow()
+	}
+	hashtag := Channel{Kind: ChannelHashtag, Hashtag: "go"}
+	if !hashtag.Matches(Event{Type: EventChirpCreated, Hashtags: []string{"go"}}, userId, nil) {
+		t.Error("Hashtag channel is missing a tagged chirp.")
+		t.FailNow()
+	}
+	notifications := Channel{Kind: ChannelNotifications}
+	if !notifications.Matches(Event{Type: EventNotification, RecipientID: userId}, userId, nil) {
+		t.Error("Notification for the user was not matched.")
+		t.FailNow()
+	}
+	if notifications.Matches(Event{Type: EventNotification, RecipientID: stranger}, userId, nil) {
+		t.Error("Another user's notification was matched.")
+		t.FailNow()
+	}
+	author := Channel{Kind: ChannelAuthor, AuthorID: stranger}
+	if author.Matches(Event{Type: EventNotification, AuthorID: stranger, RecipientID: userId}, userId, nil) {
+		t.Error("Author channel leaked a notification.")
+		t.FailNow()
+	}
+}
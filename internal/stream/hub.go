@@ -0,0 +1,131 @@
+// Package stream is an in-process pub/sub hub for live chirp events. It keeps
+// a short history so clients that reconnect can resume from the last event
+// they saw.
+package stream
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventChirpCreated = "chirp.created"
+	EventChirpDeleted = "chirp.deleted"
+	EventNotification = "notification"
+
+	subscriberBuffer = 64
+)
+
+type Event struct {
+	ID       uint64
+	Type     string
+	AuthorID uuid.UUID
+	// RecipientID is set on events meant for a single user, like notifications.
+	RecipientID uuid.UUID
+	Hashtags    []string
+	Data        json.RawMessage
+}
+
+// IsChirpEvent reports whether event is a public chirp change.
+func (event Event) IsChirpEvent() bool {
+	return event.Type == EventChirpCreated || event.Type == EventChirpDeleted
+}
+
+// Filter decides whether a subscriber receives an event.
+type Filter func(event Event) bool
+
+type Subscription struct {
+	events chan Event
+	filter Filter
+}
+
+// Events is closed when the subscription ends, including when the hub drops
+// a subscriber that fell too far behind. Such clients should reconnect and
+// resume from the last event ID they processed.
+func (subscription *Subscription) Events() <-chan Event {
+	return subscription.events
+}
+
+type Hub struct {
+	mu          sync.Mutex
+	nextID      uint64
+	history     []Event
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+// NewHub keeps the last historySize events for resuming. Event IDs start from
+// the current time so IDs issued after a restart are higher than any a client
+// saw before it, while staying small enough to be exact in JavaScript numbers.
+func NewHub(historySize int) *Hub {
+	return &Hub{
+		nextID:      uint64(time.Now().UnixMilli()) * 1000,
+		historySize: historySize,
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Publish assigns event an ID, encodes data as its payload and sends it to
+// every matching subscriber without blocking. Subscribers whose buffer is full
+// are dropped rather than slowing down the publisher.
+func (hub *Hub) Publish(event Event, data any) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.nextID++
+	event.ID = hub.nextID
+	event.Data = payload
+	hub.history = append(hub.history, event)
+	if len(hub.history) > hub.historySize {
+		hub.history = hub.history[len(hub.history)-hub.historySize:]
+	}
+	for subscription := range hub.subscribers {
+		if subscription.filter != nil && !subscription.filter(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			hub.remove(subscription)
+		}
+	}
+	return event, nil
+}
+
+// Subscribe registers a subscriber and returns the buffered events after
+// lastEventID that match filter. Nothing published in between is lost.
+func (hub *Hub) Subscribe(lastEventID uint64, filter Filter) (*Subscription, []Event) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	backlog := []Event{}
+	if lastEventID != 0 {
+		for _, event := range hub.history {
+			if event.ID > lastEventID && (filter == nil || filter(event)) {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+	subscription := &Subscription{events: make(chan Event, subscriberBuffer), filter: filter}
+	hub.subscribers[subscription] = struct{}{}
+	return subscription, backlog
+}
+
+func (hub *Hub) Unsubscribe(subscription *Subscription) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.remove(subscription)
+}
+
+func (hub *Hub) remove(subscription *Subscription) {
+	if _, ok := hub.subscribers[subscription]; !ok {
+		return
+	}
+	delete(hub.subscribers, subscription)
+	close(subscription.events)
+}
@@ -0,0 +1,77 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHubFiltersEvents(t *testing.T) {
+	hub := NewHub(10)
+	author := uuid.New()
+	subscription, backlog := hub.Subscribe(0, func(event Event) bool { return event.AuthorID == author })
+	defer hub.Unsubscribe(subscription)
+	if len(backlog) != 0 {
+		t.Errorf("Expected no backlog without Last-Event-ID, got %v events.", len(backlog))
+		t.FailNow()
+	}
+	hub.Publish(Event{Type: EventChirpCreated, AuthorID: uuid.New()}, map[string]string{"body": "other"})
+	published, _ := hub.Publish(Event{Type: EventChirpCreated, AuthorID: author}, map[string]string{"body": "mine"})
+	select {
+	case event := <-subscription.Events():
+		if event.ID != published.ID || event.Type != EventChirpCreated {
+			t.Errorf("Received unexpected event %+v.", event)
+			t.FailNow()
+		}
+	default:
+		t.Error("Matching event was not delivered.")
+		t.FailNow()
+	}
+	if len(subscription.Events()) != 0 {
+		t.Error("Event from another author was delivered.")
+		t.FailNow()
+	}
+}
+
+func TestHubResumesFromLastEventID(t *testing.T) {
+	hub := NewHub(3)
+	author := uuid.New()
+	first, _ := hub.Publish(Event{Type: EventChirpCreated, AuthorID: author}, "1")
+	for i := 0; i < 3; i++ {
+		hub.Publish(Event{Type: EventChirpCreated, AuthorID: author}, i)
+	}
+	subscription, backlog := hub.Subscribe(first.ID, nil)
+	defer hub.Unsubscribe(subscription)
+	if len(backlog) != 3 {
+		t.Errorf("Expected 3 events to resume, got %v.", len(backlog))
+		t.FailNow()
+	}
+	for i := 1; i < len(backlog); i++ {
+		if backlog[i].ID <= backlog[i-1].ID {
+			t.Error("Backlog is not in publish order.")
+			t.FailNow()
+		}
+	}
+	_, backlog = hub.Subscribe(backlog[2].ID, nil)
+	if len(backlog) != 0 {
+		t.Errorf("Expected no events after the latest ID, got %v.", len(backlog))
+		t.FailNow()
+	}
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub(10)
+	subscription, _ := hub.Subscribe(0, nil)
+	for i := 0; i < subscriberBuffer+1; i++ {
+		hub.Publish(Event{Type: EventChirpCreated, AuthorID: uuid.New()}, i)
+	}
+	received := 0
+	for range subscription.Events() {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("Expected %v buffered events before the channel closed, got %v.", subscriberBuffer, received)
+		t.FailNow()
+	}
+	hub.Unsubscribe(subscription)
+}
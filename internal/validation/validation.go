@@ -0,0 +1,105 @@
+// Package validation holds the rules for user-written text: how it is cleaned
+// before it is stored and how its length is counted. Lengths are in
+// user-perceived characters, so an emoji counts once however many code points
+// or bytes it takes.
+package validation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// URLLength is what every link counts towards a length, however long it is,
+// as if it had been shortened.
+const URLLength = 23
+
+const (
+	zeroWidthNonJoiner = '\u200c'
+	zeroWidthJoiner    = '\u200d'
+)
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// Clean strips control and invisible formatting characters, normalizes to NFC
+// and trims surrounding white space. Newlines and tabs are kept. Zero-width
+// joiners are kept only between two visible characters, where emoji sequences
+// and some scripts need them.
+func Clean(s string) string {
+	runes := []rune(s)
+	var cleaned strings.Builder
+	cleaned.Grow(len(s))
+	for i, r := range runes {
+		if r == zeroWidthJoiner || r == zeroWidthNonJoiner {
+			if i > 0 && i < len(runes)-1 && isVisible(runes[i-1]) && isVisible(runes[i+1]) {
+				cleaned.WriteRune(r)
+			}
+			continue
+		}
+		if isInvisible(r) {
+			continue
+		}
+		cleaned.WriteRune(r)
+	}
+	return strings.TrimSpace(norm.NFC.String(cleaned.String()))
+}
+
+// isInvisible reports whether r is a control or formatting character that
+// should not be stored. Tag characters stay, as they make up subdivision
+// flags like the one for Scotland.
+func isInvisible(r rune) bool {
+	switch {
+	case r == '\n' || r == '\t':
+		return false
+	case r >= '\U000e0020' && r <= '\U000e007f':
+		return false
+	case unicode.IsControl(r):
+		return true
+	case unicode.Is(unicode.Cf, r):
+		return true
+	}
+	// Hangul fillers render blank and are used to pad out names and posts.
+	return r == '\u115f' || r == '\u1160' || r == '\u3164' || r == '\uffa0'
+}
+
+func isVisible(r rune) bool {
+	return !unicode.IsSpace(r) && !isInvisible(r) && r != zeroWidthJoiner && r != zeroWidthNonJoiner
+}
+
+// URLs returns the http and https links in s, without trailing punctuation.
+func URLs(s string) []string {
+	var urls []string
+	for _, span := range urlSpans(s) {
+		urls = append(urls, s[span[0]:span[1]])
+	}
+	return urls
+}
+
+func urlSpans(s string) [][2]int {
+	var spans [][2]int
+	for _, match := range urlPattern.FindAllStringIndex(s, -1) {
+		link := strings.TrimRight(s[match[0]:match[1]], ".,;:!?'*")
+		if strings.HasSuffix(link, ")") && !strings.Contains(link, "(") {
+			link = strings.TrimRight(link, ")")
+		}
+		if len(link) <= len("https://") {
+			continue
+		}
+		spans = append(spans, [2]int{match[0], match[0] + len(link)})
+	}
+	return spans
+}
+
+// Length counts the grapheme clusters in s, with every link counted as
+// URLLength.
+func Length(s string) int {
+	length, start := 0, 0
+	for _, span := range urlSpans(s) {
+		length += uniseg.GraphemeClusterCount(s[start:span[0]]) + URLLength
+		start = span[1]
+	}
+	return length + uniseg.GraphemeClusterCount(s[start:])
+}
@@ -0,0 +1,52 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	cases := map[string]string{
+		"  hello  ":                  "hello",
+		"cafe\u0301":                 "caf\u00e9",
+		"ker\u200bfuffle":            "kerfuffle",
+		"a\x00b\u202ec\ufeff":        "abc",
+		"line one\r\nline two":       "line one\nline two",
+		"\u200d\u3164hi\u200d":       "hi",
+		"\u200b \u200b":              "",
+		"\U0001f468\u200d\U0001f469": "\U0001f468\u200d\U0001f469",
+		"\u0645\u06cc\u200c\u062e":   "\u0645\u06cc\u200c\u062e",
+	}
+	for input, expected := range cases {
+		if cleaned := Clean(input); cleaned != expected {
+			t.Errorf("Clean(%q) = %q, expected %q.", input, cleaned, expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestLength(t *testing.T) {
+	cases := map[string]int{
+		"hello":                          5,
+		strings.Repeat("\U0001f600", 50): 50,
+		"\U0001f468\u200d\U0001f469\u200d\U0001f467":            1,
+		"\U0001f44d\U0001f3fd":                                  1,
+		"cafe\u0301":                                            4,
+		"see https://example.com/a/very/long/path/that/goes/on": 4 + URLLength,
+		"(https://example.com).":                                3 + URLLength,
+	}
+	for input, expected := range cases {
+		if length := Length(input); length != expected {
+			t.Errorf("Length(%q) = %v, expected %v.", input, length, expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestURLs(t *testing.T) {
+	urls := URLs("Read https://example.com/a, then (http://example.org/b). Not https:// or ftp://x.")
+	if len(urls) != 2 || urls[0] != "https://example.com/a" || urls[1] != "http://example.org/b" {
+		t.Errorf("Unexpected URLs %q.", urls)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"Chirpy/internal/netguard"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+const (
+	FailureBlocked          = "blocked address"
+	FailureTimeout          = "timeout"
+	FailureConnection       = "connection failed"
+	FailureUnexpectedStatus = "unexpected status"
+)
+
+type ClientOptions struct {
+	Timeout time.Duration
+	// AllowAddress decides which resolved addresses may be dialed. It
+	// defaults to netguard.IsPublicAddress; tests loosen it to reach
+	// httptest servers.
+	AllowAddress func(netip.AddrPort) bool
+}
+
+// NewClient returns the client deliveries are sent with. Subscriber URLs are
+// chosen by users, so every connection is checked after DNS resolution,
+// proxies are never used and redirects are not followed: a webhook can't be
+// pointed at the server's internal network.
+func NewClient(options ClientOptions) *http.Client {
+	if options.Timeout == 0 {
+		options.Timeout = defaultTimeout
+	}
+	return &http.Client{
+		Transport: netguard.NewTransport(options.Timeout, options.AllowAddress),
+		Timeout:   options.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// FailureReason sums up why a delivery failed in terms safe to show the
+// subscriber. Raw errors would tell them which internal hosts and ports exist.
+func FailureReason(statusCode int, err error) string {
+	var netErr net.Error
+	switch {
+	case statusCode != 0:
+		return FailureUnexpectedStatus
+	case errors.Is(err, netguard.ErrBlockedAddress):
+		return FailureBlocked
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return FailureTimeout
+	}
+	return FailureConnection
+}
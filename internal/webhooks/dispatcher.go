@@ -0,0 +1,68 @@
+package webhooks
+
+import (
+	"Chirpy/internal/database"
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const deliveryBatchSize = 50
+
+type Dispatcher struct {
+	DB     *database.Queries
+	Sender *Sender
+	Logger *log.Logger
+}
+
+// DeliverDue sends every delivery whose next attempt is due. Claimed rows are
+// leased for a few minutes so a crash mid-send only delays the retry.
+func (dispatcher *Dispatcher) DeliverDue(ctx context.Context) error {
+	deliveries, err := dispatcher.DB.ClaimDueWebhookDeliveries(ctx, deliveryBatchSize)
+	if err != nil {
+		return err
+	}
+	subscriptions := map[uuid.UUID]database.WebhookSubscription{}
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = dispatcher.DB.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+			if err != nil {
+				return err
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		err = dispatcher.deliver(ctx, subscription, delivery)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dispatcher *Dispatcher) deliver(ctx context.Context, subscription database.WebhookSubscription, delivery database.WebhookDelivery) error {
+	statusCode, sendErr := dispatcher.Sender.Send(ctx, subscription.Url, subscription.Secret, delivery.ID, delivery.Event, delivery.Payload)
+	lastStatusCode := sql.NullInt32{Int32: int32(statusCode), Valid: statusCode != 0}
+	if sendErr == nil {
+		return dispatcher.DB.MarkWebhookDeliveryDelivered(ctx, database.MarkWebhookDeliveryDeliveredParams{
+			ID:             delivery.ID,
+			LastStatusCode: lastStatusCode,
+		})
+	}
+	attempts := int(delivery.Attempts) + 1
+	status := StatusPending
+	if attempts >= MaxAttempts {
+		status = StatusDead
+		dispatcher.Logger.Printf("Webhook delivery %v to %v is dead after %v attempts: %v", delivery.ID, subscription.Url, attempts, sendErr)
+	}
+	return dispatcher.DB.MarkWebhookDeliveryFailed(ctx, database.MarkWebhookDeliveryFailedParams{
+		ID:             delivery.ID,
+		Status:         status,
+		LastStatusCode: lastStatusCode,
+		LastError:      sql.NullString{String: FailureReason(statusCode, sendErr), Valid: true},
+		NextAttemptAt:  time.Now().Add(Backoff(attempts)),
+	})
+}
@@ -0,0 +1,34 @@
+package webhooks
+
+import (
+	"Chirpy/internal/database"
+	"Chirpy/internal/outbox"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Sink queues a delivery for every active subscription that listens for an
+// outbox event and either belongs to the event's user or was registered by an
+// admin. Deliveries are keyed by event ID, so republishing an event is a no-op.
+type Sink struct {
+	DB *database.Queries
+}
+
+func (sink Sink) Name() string {
+	return "webhooks"
+}
+
+func (sink Sink) Publish(ctx context.Context, event outbox.Event) error {
+	payload, err := NewPayload(event.Name, event.Payload, event.CreatedAt)
+	if err != nil {
+		return err
+	}
+	_, err = sink.DB.EnqueueWebhookDeliveries(ctx, database.EnqueueWebhookDeliveriesParams{
+		EventID: uuid.NullUUID{UUID: event.ID, Valid: true},
+		Event:   event.Name,
+		Payload: payload,
+		UserID:  uuid.NullUUID{UUID: event.UserID, Valid: event.UserID != uuid.Nil},
+	})
+	return err
+}
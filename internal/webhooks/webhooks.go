@@ -0,0 +1,150 @@
+// Package webhooks delivers Chirpy events to subscriber URLs. Deliveries are
+// queued in webhook_deliveries and sent by a background dispatcher that signs
+// each request and retries failures with exponential backoff.
+package webhooks
+
+import (
+	"Chirpy/internal/auth"
+	"Chirpy/internal/netguard"
+	"Chirpy/internal/outbox"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventChirpCreated = outbox.EventChirpCreated
+	EventChirpDeleted = outbox.EventChirpDeleted
+	EventUserUpgraded = outbox.EventUserUpgraded
+
+	// MaxAttempts is how many times a delivery is tried before it is moved to
+	// the dead state and has to be retried by hand.
+	MaxAttempts = 8
+
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+
+	SignatureHeader = "X-Chirpy-Signature"
+	EventHeader     = "X-Chirpy-Event"
+	DeliveryHeader  = "X-Chirpy-Delivery"
+)
+
+var Events = []string{EventChirpCreated, EventChirpDeleted, EventUserUpgraded}
+
+const (
+	backoffBase = 30 * time.Second
+	backoffMax  = 6 * time.Hour
+)
+
+// ValidateEvents checks that every requested event is one we publish.
+func ValidateEvents(events []string) error {
+	if len(events) == 0 {
+		return fmt.Errorf("At least one event is required.")
+	}
+	for _, event := range events {
+		if !slices.Contains(Events, event) {
+			return fmt.Errorf("Unknown event %v.", event)
+		}
+	}
+	return nil
+}
+
+// ValidateURL only accepts absolute http and https URLs on the default ports,
+// without credentials. Addresses written out in the URL must be public; host
+// names are checked again when each delivery connects.
+func ValidateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("Invalid webhook url: %w", err)
+	}
+	if (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("Webhook url must be an absolute http or https url.")
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("Webhook url cannot contain credentials.")
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = "80"
+		if parsed.Scheme == "https" {
+			port = "443"
+		}
+	}
+	if port != "80" && port != "443" {
+		return fmt.Errorf("Webhook url must use port 80 or 443.")
+	}
+	if addr, err := netip.ParseAddr(parsed.Hostname()); err == nil {
+		if !netguard.IsPublicAddress(netip.AddrPortFrom(addr, 443)) {
+			return fmt.Errorf("Webhook url must point to a public address.")
+		}
+	}
+	return nil
+}
+
+// Backoff returns how long to wait before the next attempt once attempts
+// deliveries have failed: 30s, 1m, 2m, ... capped at six hours.
+func Backoff(attempts int) time.Duration {
+	delay := backoffBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= backoffMax {
+			return backoffMax
+		}
+	}
+	return delay
+}
+
+// NewPayload wraps data in the envelope every delivery body uses.
+func NewPayload(event string, data any, now time.Time) (json.RawMessage, error) {
+	return json.Marshal(struct {
+		Event     string    `json:"event"`
+		CreatedAt time.Time `json:"created_at"`
+		Data      any       `json:"data"`
+	}{
+		Event:     event,
+		CreatedAt: now.UTC(),
+		Data:      data,
+	})
+}
+
+type Sender struct {
+	HTTPClient *http.Client
+}
+
+// Send posts payload to target signed with secret. Any response outside 2xx is
+// an error; the status code is returned whenever a response was received.
+func (sender *Sender) Send(ctx context.Context, target, secret string, deliveryId uuid.UUID, event string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Chirpy-Webhooks/1.0")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, deliveryId.String())
+	req.Header.Set(SignatureHeader, auth.SignWebhook(secret, time.Now(), payload))
+	client := sender.HTTPClient
+	if client == nil {
+		client = NewClient(ClientOptions{})
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("Webhook endpoint responded with %v.", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
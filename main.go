@@ -4,11 +4,23 @@ import (
 	"Chirpy/handlers"
 	"Chirpy/internal/config"
 	"Chirpy/internal/database"
+	"Chirpy/internal/linkpreview"
+	"Chirpy/internal/media"
+	"Chirpy/internal/notifications"
+	"Chirpy/internal/oidc"
+	"Chirpy/internal/outbox"
+	"Chirpy/internal/ranking"
+	"Chirpy/internal/ratelimit"
+	"Chirpy/internal/scheduler"
+	"Chirpy/internal/stream"
+	"Chirpy/internal/webhooks"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -39,25 +51,110 @@ func openDbConnection(dbUrl string) (*database.Queries, *sql.DB) {
 }
 
 func addHandlers(chirpyMux *http.ServeMux, apiCfg *config.ApiConfig) {
-	metricsHandler := handlers.MetricsHandler{apiCfg}
-	usersHandler := handlers.UsersHandler{apiCfg}
-	chirpHanlder := handlers.ChirpHandler{apiCfg}
+	metricsHandler := handlers.MetricsHandler{ApiConfig: apiCfg}
+	usersHandler := handlers.UsersHandler{ApiConfig: apiCfg}
+	chirpHanlder := handlers.ChirpHandler{ApiConfig: apiCfg}
+	oauthHandler := handlers.OAuthHandler{ApiConfig: apiCfg}
+	webhookHandler := handlers.WebhookHandler{ApiConfig: apiCfg}
+	moderationHandler := handlers.ModerationHandler{ApiConfig: apiCfg}
 
 	chirpyMux.Handle("/app/", metricsHandler.MiddlewareMatricInc(http.StripPrefix("/app", http.FileServer(http.Dir("./static/")))))
 	chirpyMux.Handle("/app/logo.png", metricsHandler.MiddlewareMatricInc(http.StripPrefix("/app", http.FileServer(http.Dir("./static//assets")))))
 
 	chirpyMux.HandleFunc("POST /api/users", usersHandler.HandleCreateUser)
 	chirpyMux.HandleFunc("PUT /api/users", usersHandler.HandlerUpdateUser)
+	chirpyMux.HandleFunc("GET /api/users/me/subscription", usersHandler.HandlerGetSubscription)
+	chirpyMux.HandleFunc("POST /api/users/2fa/enroll", usersHandler.HandlerEnrollTwoFactor)
+	chirpyMux.HandleFunc("POST /api/users/2fa/confirm", usersHandler.HandlerConfirmTwoFactor)
+	chirpyMux.HandleFunc("POST /api/users/api_keys", usersHandler.HandlerCreateAPIKey)
+	chirpyMux.HandleFunc("GET /api/users/api_keys", usersHandler.HandlerGetAPIKeys)
+	chirpyMux.HandleFunc("DELETE /api/users/api_keys/{keyID}", usersHandler.HandlerDeleteAPIKey)
+	chirpyMux.HandleFunc("POST /api/follows/{userID}", usersHandler.HandlerFollowUser)
+	chirpyMux.HandleFunc("DELETE /api/follows/{userID}", usersHandler.HandlerUnfollowUser)
+	chirpyMux.HandleFunc("GET /api/notifications", usersHandler.HandlerGetNotifications)
+	chirpyMux.HandleFunc("GET /api/notifications/unread_count", usersHandler.HandlerGetUnreadNotificationCount)
+	chirpyMux.HandleFunc("POST /api/notifications/read_all", usersHandler.HandlerMarkAllNotificationsRead)
+	chirpyMux.HandleFunc("POST /api/notifications/{notificationID}/read", usersHandler.HandlerMarkNotificationRead)
+	chirpyMux.HandleFunc("GET /api/notifications/preferences", usersHandler.HandlerGetNotificationPreferences)
+	chirpyMux.HandleFunc("PUT /api/notifications/preferences", usersHandler.HandlerUpdateNotificationPreferences)
+	chirpyMux.HandleFunc("POST /api/blocks/{userID}", usersHandler.HandlerBlockUser)
+	chirpyMux.HandleFunc("DELETE /api/blocks/{userID}", usersHandler.HandlerUnblockUser)
+	chirpyMux.HandleFunc("GET /api/blocks", usersHandler.HandlerGetBlocks)
+	chirpyMux.HandleFunc("POST /api/mutes/{userID}", usersHandler.HandlerMuteUser)
+	chirpyMux.HandleFunc("DELETE /api/mutes/{userID}", usersHandler.HandlerUnmuteUser)
+	chirpyMux.HandleFunc("GET /api/mutes", usersHandler.HandlerGetMutes)
+	chirpyMux.HandleFunc("POST /api/conversations", usersHandler.HandlerCreateConversation)
+	chirpyMux.HandleFunc("GET /api/conversations", usersHandler.HandlerGetConversations)
+	chirpyMux.HandleFunc("GET /api/conversations/{conversationID}", usersHandler.HandlerGetConversation)
+	chirpyMux.HandleFunc("POST /api/conversations/{conversationID}/messages", usersHandler.HandlerSendMessage)
+	chirpyMux.HandleFunc("GET /api/conversations/{conversationID}/messages", usersHandler.HandlerGetMessages)
+	chirpyMux.HandleFunc("POST /api/conversations/{conversationID}/read", usersHandler.HandlerMarkConversationRead)
 	chirpyMux.HandleFunc("POST /api/login", usersHandler.HandlerLogin)
+	chirpyMux.HandleFunc("POST /api/login/2fa", usersHandler.HandlerLoginTwoFactor)
+	chirpyMux.HandleFunc("GET /api/auth/oidc/login", usersHandler.HandlerOIDCLogin)
+	chirpyMux.HandleFunc("POST /api/auth/oidc/link", usersHandler.HandlerOIDCLink)
+	chirpyMux.HandleFunc("GET /api/auth/oidc/callback", usersHandler.HandlerOIDCCallback)
 	chirpyMux.HandleFunc("POST /api/refresh", usersHandler.HandlerRefresh)
 	chirpyMux.HandleFunc("POST /api/revoke", usersHandler.HandlerRevoke)
 
 	chirpyMux.HandleFunc("POST /api/chirps", chirpHanlder.HandlerCreateChirp)
 	chirpyMux.HandleFunc("GET /api/chirps", chirpHanlder.HandlerGetAllCirps)
+	chirpyMux.HandleFunc("GET /api/chirps/explore", chirpHanlder.HandlerGetExploreChirps)
+	chirpyMux.HandleFunc("GET /api/chirps/scheduled", chirpHanlder.HandlerGetScheduledChirps)
+	chirpyMux.HandleFunc("DELETE /api/chirps/{chirpID}/schedule", chirpHanlder.HandlerCancelScheduledChirp)
 	chirpyMux.HandleFunc("GET /api/chirps/{chirpID}", chirpHanlder.HandlerGetOneCirps)
+	chirpyMux.HandleFunc("PUT /api/chirps/{chirpID}", chirpHanlder.HandlerUpdateChirp)
 	chirpyMux.HandleFunc("DELETE /api/chirps/{chirpID}", chirpHanlder.HandlerDeleteCirp)
+	chirpyMux.HandleFunc("POST /api/chirps/{chirpID}/like", chirpHanlder.HandlerLikeChirp)
+	chirpyMux.HandleFunc("DELETE /api/chirps/{chirpID}/like", chirpHanlder.HandlerUnlikeChirp)
+	chirpyMux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", chirpHanlder.HandlerBookmarkChirp)
+	chirpyMux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", chirpHanlder.HandlerUnbookmarkChirp)
+	chirpyMux.HandleFunc("POST /api/chirps/{chirpID}/pin", chirpHanlder.HandlerPinChirp)
+	chirpyMux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", chirpHanlder.HandlerUnpinChirp)
+	chirpyMux.HandleFunc("POST /api/chirps/{chirpID}/report", chirpHanlder.HandlerReportChirp)
+	chirpyMux.HandleFunc("POST /api/chirps/{chirpID}/poll/vote", chirpHanlder.HandlerVotePoll)
+	chirpyMux.HandleFunc("POST /api/media", chirpHanlder.HandlerUploadMedia)
+	chirpyMux.HandleFunc("GET /api/media/{attachmentID}", chirpHanlder.HandlerGetMedia)
+	chirpyMux.HandleFunc("GET /api/media/{attachmentID}/thumbnail", chirpHanlder.HandlerGetMediaThumbnail)
+	chirpyMux.HandleFunc("POST /api/drafts", chirpHanlder.HandlerCreateDraft)
+	chirpyMux.HandleFunc("GET /api/drafts", chirpHanlder.HandlerGetDrafts)
+	chirpyMux.HandleFunc("GET /api/drafts/{draftID}", chirpHanlder.HandlerGetDraft)
+	chirpyMux.HandleFunc("PUT /api/drafts/{draftID}", chirpHanlder.HandlerUpdateDraft)
+	chirpyMux.HandleFunc("DELETE /api/drafts/{draftID}", chirpHanlder.HandlerDeleteDraft)
+	chirpyMux.HandleFunc("POST /api/drafts/{draftID}/publish", chirpHanlder.HandlerPublishDraft)
+	chirpyMux.HandleFunc("GET /api/bookmarks", chirpHanlder.HandlerGetBookmarks)
+	chirpyMux.HandleFunc("POST /api/bookmarks/collections", chirpHanlder.HandlerCreateBookmarkCollection)
+	chirpyMux.HandleFunc("GET /api/bookmarks/collections", chirpHanlder.HandlerGetBookmarkCollections)
+	chirpyMux.HandleFunc("PUT /api/bookmarks/collections/{collectionID}", chirpHanlder.HandlerRenameBookmarkCollection)
+	chirpyMux.HandleFunc("DELETE /api/bookmarks/collections/{collectionID}", chirpHanlder.HandlerDeleteBookmarkCollection)
+	chirpyMux.HandleFunc("GET /api/stream", chirpHanlder.HandlerStream)
+	chirpyMux.HandleFunc("GET /api/ws", chirpHanlder.HandlerWebSocket)
 
-	chirpyMux.HandleFunc("POST /api/polka/webhooks", usersHandler.HandlerUpgradeUser)
+	chirpyMux.HandleFunc("POST /api/oauth/clients", oauthHandler.HandlerRegisterClient)
+	chirpyMux.HandleFunc("GET /api/oauth/authorize", oauthHandler.HandlerGetAuthorize)
+	chirpyMux.HandleFunc("POST /api/oauth/authorize", oauthHandler.HandlerPostAuthorize)
+	chirpyMux.HandleFunc("POST /api/oauth/token", oauthHandler.HandlerToken)
+	chirpyMux.HandleFunc("POST /api/oauth/introspect", oauthHandler.HandlerIntrospect)
+	chirpyMux.HandleFunc("GET /api/oauth/consents", oauthHandler.HandlerGetConsents)
+	chirpyMux.HandleFunc("DELETE /api/oauth/consents/{clientID}", oauthHandler.HandlerRevokeConsent)
+
+	chirpyMux.HandleFunc("POST /api/polka/webhooks", usersHandler.HandlerPolkaWebhook)
+
+	chirpyMux.HandleFunc("POST /api/webhooks", webhookHandler.HandlerCreateWebhook)
+	chirpyMux.HandleFunc("GET /api/webhooks", webhookHandler.HandlerGetWebhooks)
+	chirpyMux.HandleFunc("DELETE /api/webhooks/{webhookID}", webhookHandler.HandlerDeleteWebhook)
+	chirpyMux.HandleFunc("GET /api/webhooks/{webhookID}/deliveries", webhookHandler.HandlerGetWebhookDeliveries)
+	chirpyMux.HandleFunc("POST /api/webhooks/{webhookID}/deliveries/{deliveryID}/retry", webhookHandler.HandlerRetryWebhookDelivery)
+
+	chirpyMux.HandleFunc("GET /api/moderation/reports", moderationHandler.HandlerGetModerationQueue)
+	chirpyMux.HandleFunc("GET /api/moderation/chirps/{chirpID}/reports", moderationHandler.HandlerGetChirpReports)
+	chirpyMux.HandleFunc("POST /api/moderation/chirps/{chirpID}/actions", moderationHandler.HandlerModerateChirp)
+	chirpyMux.HandleFunc("GET /api/moderation/actions", moderationHandler.HandlerGetModerationActions)
+	chirpyMux.HandleFunc("POST /api/moderation/users/{userID}/suspension", moderationHandler.HandlerSuspendUser)
+	chirpyMux.HandleFunc("DELETE /api/moderation/users/{userID}/suspension", moderationHandler.HandlerUnsuspendUser)
+	chirpyMux.HandleFunc("GET /api/moderators", moderationHandler.HandlerGetModerators)
+	chirpyMux.HandleFunc("POST /api/moderators/{userID}", moderationHandler.HandlerAddModerator)
+	chirpyMux.HandleFunc("DELETE /api/moderators/{userID}", moderationHandler.HandlerRemoveModerator)
 
 	chirpyMux.HandleFunc("GET /api/healthz", handlerHealth)
 
@@ -65,17 +162,97 @@ func addHandlers(chirpyMux *http.ServeMux, apiCfg *config.ApiConfig) {
 	chirpyMux.HandleFunc("POST /admin/reset", metricsHandler.HandlerReset)
 }
 
-func getEnv() (string, string, string, string) {
+const outboxRetention = 7 * 24 * time.Hour
+
+// newOutboxDispatcher wires the sinks that receive domain events. Development
+// servers also log every event.
+func newOutboxDispatcher(apiCfg *config.ApiConfig) *outbox.Dispatcher {
+	sinks := []outbox.Sink{
+		webhooks.Sink{DB: apiCfg.DB},
+		notifications.Sink{DB: apiCfg.DB, Stream: apiCfg.Stream, Logger: apiCfg.Logger},
+	}
+	if apiCfg.Platform == "dev" {
+		sinks = append(sinks, outbox.LogSink{Logger: apiCfg.Logger})
+	}
+	return outbox.NewDispatcher(apiCfg.DB, apiCfg.Logger, 5*time.Second, sinks...)
+}
+
+func addJobs(jobScheduler *scheduler.Scheduler, apiCfg *config.ApiConfig) {
+	jobScheduler.Add("expire-subscriptions", time.Minute, func(ctx context.Context) error {
+		expired, err := apiCfg.DB.ExpireLapsedSubscriptions(ctx)
+		if expired > 0 {
+			apiCfg.Logger.Printf("Expired %v lapsed subscriptions", expired)
+		}
+		return err
+	})
+	chirpHanlder := handlers.ChirpHandler{ApiConfig: apiCfg}
+	jobScheduler.Add("publish-scheduled-chirps", 30*time.Second, chirpHanlder.PublishScheduledChirps)
+	jobScheduler.Add("prune-attachments", time.Hour, chirpHanlder.PruneAttachments)
+	webhookDispatcher := webhooks.Dispatcher{
+		DB:     apiCfg.DB,
+		Sender: &webhooks.Sender{HTTPClient: webhooks.NewClient(webhooks.ClientOptions{})},
+		Logger: apiCfg.Logger,
+	}
+	jobScheduler.Add("deliver-webhooks", 10*time.Second, webhookDispatcher.DeliverDue)
+	unfurler := linkpreview.Unfurler{
+		DB:      apiCfg.DB,
+		Fetcher: linkpreview.NewFetcher(linkpreview.Options{}),
+		Logger:  apiCfg.Logger,
+	}
+	jobScheduler.Add("unfurl-links", 10*time.Second, unfurler.UnfurlDue)
+	// Start runs every job once right away, so explore is ranked as soon as
+	// the server is up rather than 5 minutes later.
+	ranker := ranking.Ranker{DB: apiCfg.DB, SQLDB: apiCfg.SQLDB}
+	jobScheduler.Add("rank-chirps", 5*time.Minute, ranker.Refresh)
+	jobScheduler.Add("prune-outbox", time.Hour, func(ctx context.Context) error {
+		_, err := apiCfg.DB.DeletePublishedOutboxEvents(ctx, sql.NullTime{Time: time.Now().Add(-outboxRetention), Valid: true})
+		return err
+	})
+	jobScheduler.Add("prune-rate-limits", time.Minute, func(ctx context.Context) error {
+		apiCfg.RateLimiter.Prune()
+		return nil
+	})
+}
+
+func getEnv() (string, string, string, string, string) {
 	godotenv.Load()
 	dbUrl := os.Getenv("DB_URL")
 	platform := os.Getenv("PLATFORM")
 	jwtSecret := os.Getenv("JWTSECRET")
 	polkaKey := os.Getenv("POLKA_KEY")
-	return dbUrl, platform, jwtSecret, polkaKey
+	adminKey := os.Getenv("ADMIN_KEY")
+	return dbUrl, platform, jwtSecret, polkaKey, adminKey
+}
+
+// getMediaStore stores uploads under MEDIA_DIR, or ./media by default.
+func getMediaStore() *media.LocalStore {
+	dir := os.Getenv("MEDIA_DIR")
+	if dir == "" {
+		dir = "./media"
+	}
+	store, err := media.NewLocalStore(dir)
+	if err != nil {
+		log.Fatal(fmt.Errorf("Opening the media directory failed: %w", err))
+	}
+	return store
+}
+
+// getOIDCProvider returns nil when no external identity provider is configured.
+func getOIDCProvider() *oidc.Provider {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil
+	}
+	return oidc.NewProvider(oidc.Config{
+		Issuer:       issuer,
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+	})
 }
 
 func main() {
-	dbUrl, platform, jwtSecret, polkaKey := getEnv()
+	dbUrl, platform, jwtSecret, polkaKey, adminKey := getEnv()
 	dbQueries, db := openDbConnection(dbUrl)
 	newLogger, file := createLogger()
 	defer func() {
@@ -85,15 +262,28 @@ func main() {
 	port := "8080"
 	chirpyMux := http.NewServeMux()
 	apiCfg := config.ApiConfig{
-		Logger:    newLogger,
-		DB:        dbQueries,
-		Platform:  platform,
-		JWTSecret: jwtSecret,
-		PolkaKey:  polkaKey,
+		Logger:      newLogger,
+		DB:          dbQueries,
+		SQLDB:       db,
+		Platform:    platform,
+		JWTSecret:   jwtSecret,
+		PolkaKey:    polkaKey,
+		AdminKey:    adminKey,
+		OIDC:        getOIDCProvider(),
+		RateLimiter: ratelimit.New(time.Minute),
+		Stream:      stream.NewHub(1000),
+		Media:       getMediaStore(),
 	}
+	apiCfg.Outbox = newOutboxDispatcher(&apiCfg)
 	addHandlers(chirpyMux, &apiCfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	apiCfg.Outbox.Start(ctx)
+	jobScheduler := scheduler.New(newLogger)
+	addJobs(jobScheduler, &apiCfg)
+	jobScheduler.Start(ctx)
 	server := http.Server{
-		Handler: chirpyMux,
+		Handler: handlers.MiddlewareRejectSuspended(&apiCfg, chirpyMux),
 		Addr:    ":" + port,
 	}
 	apiCfg.Logger.Printf("Chirpy running on localhost:%v\n", port)
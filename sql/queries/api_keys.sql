@@ -0,0 +1,14 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys(id, user_id, name, key_hash, prefix, scopes, expires_at, created_at, updated_at) values(gen_random_uuid(), $1, $2, $3, $4, $5, $6, Now(), Now()) returning *;
+
+-- name: GetAPIKeyByHash :one
+SELECT * from api_keys where key_hash = $1 LIMIT 1;
+
+-- name: GetAPIKeysForUser :many
+SELECT * from api_keys where user_id = $1 order by created_at desc;
+
+-- name: DeleteAPIKey :one
+DELETE from api_keys where id = $1 and user_id = $2 returning *;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys set last_used_at = Now() where id = $1;
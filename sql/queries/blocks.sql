@@ -0,0 +1,14 @@
+-- name: HasBlockBetween :one
+select exists(select 1 from blocks where (blocker_id = $1 and blocked_id = $2) or (blocker_id = $2 and blocked_id = $1));
+
+-- name: HasBlockInConversation :one
+select exists(select 1 from conversation_members m join blocks b on (b.blocker_id = m.user_id and b.blocked_id = sqlc.arg(user_id)) or (b.blocker_id = sqlc.arg(user_id) and b.blocked_id = m.user_id) where m.conversation_id = sqlc.arg(conversation_id) and m.user_id <> sqlc.arg(user_id));
+
+-- name: BlockUser :execrows
+INSERT INTO blocks(blocker_id, blocked_id, created_at) values($1, $2, Now()) ON CONFLICT (blocker_id, blocked_id) DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE from blocks where blocker_id = $1 and blocked_id = $2;
+
+-- name: GetBlocks :many
+select * from blocks where blocker_id = $1 order by created_at desc;
@@ -0,0 +1,35 @@
+-- name: CreateBookmarkCollection :one
+INSERT INTO bookmark_collections(id, user_id, name, created_at, updated_at) VALUES(gen_random_uuid(), $1, $2, Now(), Now())
+ON CONFLICT (user_id, lower(name)) DO NOTHING returning *;
+
+-- name: GetBookmarkCollection :one
+select * from bookmark_collections where id = $1 and user_id = $2;
+
+-- name: GetBookmarkCollections :many
+select bc.id, bc.name, bc.created_at, bc.updated_at, count(b.chirp_id) as bookmark_count from bookmark_collections bc
+left join bookmarks b on b.collection_id = bc.id
+where bc.user_id = $1 group by bc.id order by bc.created_at asc;
+
+-- name: RenameBookmarkCollection :one
+UPDATE bookmark_collections set name = $3, updated_at = Now() where id = $1 and user_id = $2
+and NOT EXISTS (select 1 from bookmark_collections other where other.user_id = $2 and lower(other.name) = lower($3) and other.id <> $1)
+returning *;
+
+-- name: DeleteBookmarkCollection :execrows
+DELETE from bookmark_collections where id = $1 and user_id = $2;
+
+-- name: UpsertBookmark :exec
+INSERT INTO bookmarks(user_id, chirp_id, collection_id, created_at) VALUES($1, $2, $3, Now())
+ON CONFLICT (user_id, chirp_id) DO UPDATE set collection_id = excluded.collection_id;
+
+-- name: DeleteBookmark :execrows
+DELETE from bookmarks where user_id = $1 and chirp_id = $2;
+
+-- name: GetBookmarks :many
+select * from bookmarks where user_id = sqlc.arg(user_id) and (sqlc.narg(before_at)::timestamptz IS NULL or (created_at, chirp_id) < (sqlc.narg(before_at)::timestamptz, sqlc.narg(before_id)::uuid)) order by created_at desc, chirp_id desc LIMIT sqlc.arg(max_results);
+
+-- name: GetBookmarksInCollection :many
+select * from bookmarks where user_id = sqlc.arg(user_id) and collection_id = sqlc.arg(collection_id) and (sqlc.narg(before_at)::timestamptz IS NULL or (created_at, chirp_id) < (sqlc.narg(before_at)::timestamptz, sqlc.narg(before_id)::uuid)) order by created_at desc, chirp_id desc LIMIT sqlc.arg(max_results);
+
+-- name: GetBookmarkedChirpIDs :many
+select chirp_id from bookmarks where user_id = sqlc.arg(user_id) and chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
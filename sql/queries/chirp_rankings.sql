@@ -0,0 +1,20 @@
+-- name: GetChirpEngagementSince :many
+select c.id, coalesce(c.publish_at, c.created_at)::timestamptz as posted_at,
+(select count(*) from likes l where l.chirp_id = c.id) as likes,
+(select count(*) from chirps r where r.reply_to_id = c.id and r.published = true and r.hidden_at IS NULL) as replies
+from chirps c where c.published = true and c.hidden_at IS NULL and coalesce(c.publish_at, c.created_at) > sqlc.arg(since);
+
+-- name: GetRankingTime :one
+select Now()::timestamptz as now;
+
+-- name: LockChirpRankings :exec
+select pg_advisory_xact_lock(hashtext('chirp_rankings'));
+
+-- name: DeleteChirpRankings :exec
+DELETE from chirp_rankings;
+
+-- name: CreateChirpRanking :exec
+INSERT INTO chirp_rankings(rank, chirp_id, score, ranked_at) values($1, $2, $3, $4);
+
+-- name: GetChirpRankings :many
+select * from chirp_rankings where rank > sqlc.arg(after_rank) order by rank asc LIMIT sqlc.arg(max_results);
@@ -1,14 +1,35 @@
 -- name: CreateChirp :one
-INSERT INTO chirps(id, created_at, updated_at, body, user_id)VALUES( gen_random_uuid(), Now(), Now(), $1, $2) returning *;
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, reply_to_id)VALUES( gen_random_uuid(), Now(), Now(), $1, $2, $3) returning *;
+
+-- name: CreateScheduledChirp :one
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, reply_to_id, published, publish_at) VALUES(gen_random_uuid(), sqlc.arg(publish_at), Now(), sqlc.arg(body), sqlc.arg(user_id), sqlc.arg(reply_to_id), false, sqlc.arg(publish_at)) returning *;
 
 -- name: GetOneChirp :one
 select * from chirps where id = $1 LIMIT 1;
 
 -- name: GetAllChirps :many
-select * from chirps order by created_at asc;
+select * from chirps where published = true and hidden_at IS NULL order by created_at asc;
 
 -- name: DeleteChirp :one
 DELETE  from chirps where id = $1 returning *;
 
+-- name: DeleteOwnChirp :one
+DELETE from chirps where id = $1 and user_id = $2 returning *;
+
 -- name: GetChirpsByAuthor :many
-select * from chirps where user_id= $1 order by created_at asc;
+select * from chirps where user_id= $1 and published = true and hidden_at IS NULL order by created_at asc;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps set body = $1, edited_at = Now(), updated_at = Now() where id = $2 returning *;
+
+-- name: PublishDueChirps :many
+UPDATE chirps set published = true, updated_at = Now() where published = false and publish_at <= Now() returning *;
+
+-- name: GetScheduledChirps :many
+select * from chirps where user_id = $1 and published = false order by publish_at asc;
+
+-- name: DeleteScheduledChirp :one
+DELETE from chirps where id = $1 and user_id = $2 and published = false returning *;
+
+-- name: GetVisibleChirpsByIDs :many
+select * from chirps where id = ANY(sqlc.arg(ids)::uuid[]) and published = true and hidden_at IS NULL;
@@ -0,0 +1,17 @@
+-- name: CreateDraft :one
+INSERT INTO drafts(id, user_id, body, reply_to_id, created_at, updated_at) VALUES(gen_random_uuid(), $1, $2, $3, Now(), Now()) returning *;
+
+-- name: CountDrafts :one
+select count(*) from drafts where user_id = $1;
+
+-- name: GetDraft :one
+select * from drafts where id = $1 and user_id = $2;
+
+-- name: GetDrafts :many
+select * from drafts where user_id = sqlc.arg(user_id) and (sqlc.narg(before_at)::timestamptz IS NULL or (updated_at, id) < (sqlc.narg(before_at)::timestamptz, sqlc.narg(before_id)::uuid)) order by updated_at desc, id desc LIMIT sqlc.arg(max_results);
+
+-- name: UpdateDraft :one
+UPDATE drafts set body = $3, reply_to_id = $4, updated_at = Now() where id = $1 and user_id = $2 returning *;
+
+-- name: DeleteDraft :execrows
+DELETE from drafts where id = $1 and user_id = $2;
@@ -0,0 +1,11 @@
+-- name: FollowUser :execrows
+INSERT INTO follows(follower_id, followee_id, created_at) values($1, $2, Now()) ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE from follows where follower_id = $1 and followee_id = $2;
+
+-- name: GetUnmutedFolloweeIDs :many
+select followee_id from follows where follower_id = $1 and followee_id not in (select muted_id from mutes where muter_id = $1);
+
+-- name: DeleteFollowsBetween :exec
+DELETE from follows where (follower_id = $1 and followee_id = $2) or (follower_id = $2 and followee_id = $1);
@@ -0,0 +1,5 @@
+-- name: LikeChirp :execrows
+INSERT INTO likes(user_id, chirp_id, created_at) values($1, $2, Now()) ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnlikeChirp :execrows
+DELETE from likes where user_id = $1 and chirp_id = $2;
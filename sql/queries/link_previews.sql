@@ -0,0 +1,22 @@
+-- name: SetChirpLink :exec
+UPDATE chirps set link_url = $2 where id = $1;
+
+-- name: QueueLinkPreview :exec
+INSERT INTO link_previews(url) VALUES($1)
+ON CONFLICT (url) DO UPDATE set status = 'pending', attempts = 0, next_attempt_at = Now(), updated_at = Now()
+where link_previews.status <> 'pending' and link_previews.updated_at < Now() - interval '7 days';
+
+-- name: ClaimDueLinkPreviews :many
+UPDATE link_previews set next_attempt_at = Now() + interval '5 minutes', updated_at = Now()
+where url IN (select url from link_previews where status = 'pending' and next_attempt_at <= Now() order by next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+returning *;
+
+-- name: MarkLinkPreviewReady :exec
+UPDATE link_previews set status = 'ready', title = $2, description = $3, image_url = $4, site_name = $5, attempts = attempts + 1, last_error = NULL, fetched_at = Now(), updated_at = Now() where url = $1;
+
+-- name: MarkLinkPreviewFailed :exec
+UPDATE link_previews set status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4, updated_at = Now() where url = $1;
+
+-- name: GetLinkPreviewsForChirps :many
+select c.id as chirp_id, lp.url, lp.title, lp.description, lp.image_url, lp.site_name from chirps c join link_previews lp on lp.url = c.link_url
+where c.id = ANY(sqlc.arg(chirp_ids)::uuid[]) and lp.fetched_at IS NOT NULL;
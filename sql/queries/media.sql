@@ -0,0 +1,14 @@
+-- name: CreateAttachment :one
+INSERT INTO attachments(id, user_id, content_type, size_bytes, width, height, blob_key, thumbnail_key, created_at) VALUES($1, $2, $3, $4, $5, $6, $7, $8, Now()) returning *;
+
+-- name: GetAttachment :one
+select * from attachments where id = $1;
+
+-- name: AttachToChirp :execrows
+UPDATE attachments set chirp_id = sqlc.arg(chirp_id), position = array_position(sqlc.arg(ids)::uuid[], id), attached_at = Now() where id = ANY(sqlc.arg(ids)::uuid[]) and user_id = sqlc.arg(user_id) and chirp_id IS NULL and attached_at IS NULL;
+
+-- name: GetAttachmentsForChirps :many
+select * from attachments where chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]) order by chirp_id, position;
+
+-- name: DeleteDetachedAttachments :many
+DELETE from attachments where chirp_id IS NULL and (attached_at IS NOT NULL or created_at < $1) returning *;
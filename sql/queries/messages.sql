@@ -0,0 +1,35 @@
+-- name: CreateConversation :one
+INSERT INTO conversations(id, created_by, is_group, title, created_at, last_message_at) values(gen_random_uuid(), $1, $2, $3, Now(), Now()) returning *;
+
+-- name: AddConversationMember :exec
+INSERT INTO conversation_members(conversation_id, user_id, joined_at) values($1, $2, Now());
+
+-- name: CreateDirectConversation :one
+INSERT INTO conversations(id, created_by, is_group, direct_key, created_at, last_message_at) values(gen_random_uuid(), $1, false, $2, Now(), Now()) ON CONFLICT (direct_key) DO NOTHING returning *;
+
+-- name: GetDirectConversation :one
+select * from conversations where direct_key = $1;
+
+-- name: GetConversationForMember :one
+select c.* from conversations c join conversation_members m on m.conversation_id = c.id where c.id = $1 and m.user_id = $2;
+
+-- name: GetConversationsForUser :many
+select c.id, c.created_by, c.is_group, c.title, c.created_at, c.last_message_at, m.last_read_at,
+(select count(*) from messages where conversation_id = c.id and sender_id <> m.user_id and (m.last_read_at IS NULL or created_at > m.last_read_at)) as unread_count
+from conversations c join conversation_members m on m.conversation_id = c.id
+where m.user_id = sqlc.arg(user_id) and (sqlc.narg(before_at)::timestamptz IS NULL or (c.last_message_at, c.id) < (sqlc.narg(before_at)::timestamptz, sqlc.narg(before_id)::uuid)) order by c.last_message_at desc, c.id desc LIMIT sqlc.arg(max_results);
+
+-- name: GetConversationMembers :many
+select * from conversation_members where conversation_id = $1 order by joined_at;
+
+-- name: CreateMessage :one
+INSERT INTO messages(id, conversation_id, sender_id, body, created_at) values(gen_random_uuid(), $1, $2, $3, Now()) returning *;
+
+-- name: TouchConversation :exec
+UPDATE conversations set last_message_at = $2 where id = $1;
+
+-- name: GetMessages :many
+select * from messages where conversation_id = sqlc.arg(conversation_id) and (sqlc.narg(before_at)::timestamptz IS NULL or (created_at, id) < (sqlc.narg(before_at)::timestamptz, sqlc.narg(before_id)::uuid)) order by created_at desc, id desc LIMIT sqlc.arg(max_results);
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members set last_read_at = Now() where conversation_id = $1 and user_id = $2;
@@ -0,0 +1,44 @@
+-- name: AddModerator :execrows
+INSERT INTO moderators(user_id, created_at) values($1, Now()) ON CONFLICT (user_id) DO NOTHING;
+
+-- name: RemoveModerator :execrows
+DELETE from moderators where user_id = $1;
+
+-- name: GetModerators :many
+select * from moderators order by created_at;
+
+-- name: IsModerator :one
+select exists(select 1 from moderators where user_id = $1);
+
+-- name: CreateReport :one
+INSERT INTO reports(id, chirp_id, reporter_id, reason, details, status, created_at) values(gen_random_uuid(), $1, $2, $3, $4, 'pending', Now())
+ON CONFLICT (chirp_id, reporter_id) DO UPDATE set reason = excluded.reason, details = excluded.details, status = 'pending', created_at = Now(), resolved_at = NULL
+returning *;
+
+-- name: GetModerationQueue :many
+select r.chirp_id, c.user_id as author_id, c.body, c.hidden_at, count(*) as report_count, array_agg(r.reason order by r.created_at)::text[] as reasons, min(r.created_at)::timestamptz as first_reported_at, max(r.created_at)::timestamptz as last_reported_at
+from reports r join chirps c on c.id = r.chirp_id
+where r.status = 'pending'
+group by r.chirp_id, c.user_id, c.body, c.hidden_at
+order by count(*) desc, min(r.created_at) asc LIMIT $1;
+
+-- name: GetPendingReportsForChirp :many
+select * from reports where chirp_id = $1 and status = 'pending' order by created_at;
+
+-- name: ResolveReportsForChirp :execrows
+UPDATE reports set status = 'resolved', resolved_at = Now() where chirp_id = $1 and status = 'pending';
+
+-- name: HideChirp :one
+UPDATE chirps set hidden_at = COALESCE(hidden_at, Now()), updated_at = Now() where id = $1 returning *;
+
+-- name: SuspendUser :one
+UPDATE users set suspended_at = Now(), suspended_until = $2, suspension_reason = $3, updated_at = Now() where id = $1 returning *;
+
+-- name: UnsuspendUser :execrows
+UPDATE users set suspended_at = NULL, suspended_until = NULL, suspension_reason = NULL, updated_at = Now() where id = $1 and suspended_at IS NOT NULL;
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions(id, moderator_id, action, chirp_id, target_user_id, note, reports_resolved, created_at) values(gen_random_uuid(), $1, $2, $3, $4, $5, $6, Now()) returning *;
+
+-- name: GetModerationActions :many
+select * from moderation_actions where (sqlc.narg(before_at)::timestamptz IS NULL or (created_at, id) < (sqlc.narg(before_at)::timestamptz, sqlc.narg(before_id)::uuid)) order by created_at desc, id desc LIMIT sqlc.arg(max_results);
@@ -0,0 +1,11 @@
+-- name: MuteUser :execrows
+INSERT INTO mutes(muter_id, muted_id, created_at) values($1, $2, Now()) ON CONFLICT (muter_id, muted_id) DO NOTHING;
+
+-- name: UnmuteUser :execrows
+DELETE from mutes where muter_id = $1 and muted_id = $2;
+
+-- name: GetMutes :many
+select * from mutes where muter_id = $1 order by created_at desc;
+
+-- name: GetMutedUserIDs :many
+select muted_id from mutes where muter_id = $1;
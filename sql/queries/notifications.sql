@@ -0,0 +1,26 @@
+-- name: CreateNotification :one
+INSERT INTO notifications(id, user_id, event_id, type, actor_id, chirp_id, created_at) values(gen_random_uuid(), $1, $2, $3, $4, $5, Now())
+ON CONFLICT (event_id, user_id, type) DO NOTHING returning *;
+
+-- name: GetNotifications :many
+select * from notifications where user_id = sqlc.arg(user_id) and (sqlc.narg(before_at)::timestamptz IS NULL or (created_at, id) < (sqlc.narg(before_at)::timestamptz, sqlc.narg(before_id)::uuid)) and (sqlc.arg(unread_only)::boolean = false or read_at IS NULL) order by created_at desc, id desc LIMIT sqlc.arg(max_results);
+
+-- name: CountUnreadNotifications :one
+select count(*) from notifications where user_id = $1 and read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications set read_at = COALESCE(read_at, Now()) where id = $1 and user_id = $2;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications set read_at = Now() where user_id = $1 and read_at IS NULL;
+
+-- name: GetNotificationPreferences :one
+select * from notification_preferences where user_id = $1;
+
+-- name: UpsertNotificationPreferences :one
+INSERT INTO notification_preferences(user_id, mentions, replies, follows, likes, chirpy_red, updated_at) values($1, $2, $3, $4, $5, $6, Now())
+ON CONFLICT (user_id) DO UPDATE set mentions = excluded.mentions, replies = excluded.replies, follows = excluded.follows, likes = excluded.likes, chirpy_red = excluded.chirpy_red, updated_at = Now()
+returning *;
+
+-- name: GetUsersByEmails :many
+select * from users where email = ANY(sqlc.arg(emails)::text[]);
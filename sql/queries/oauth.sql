@@ -0,0 +1,32 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients(id, owner_id, name, secret_hash, redirect_uris, created_at, updated_at) values(gen_random_uuid(), $1, $2, $3, $4, Now(), Now()) returning *;
+
+-- name: GetOAuthClient :one
+SELECT * from oauth_clients where id = $1 LIMIT 1;
+
+-- name: CreateOAuthAuthorizationCode :one
+INSERT INTO oauth_authorization_codes(code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at) values($1, $2, $3, $4, $5, $6, $7) returning *;
+
+-- name: ConsumeOAuthAuthorizationCode :one
+DELETE from oauth_authorization_codes where code_hash = $1 returning *;
+
+-- name: UpsertOAuthConsent :one
+INSERT INTO oauth_consents(user_id, client_id, scopes, created_at, updated_at) values($1, $2, $3, Now(), Now()) ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = excluded.scopes, updated_at = Now() returning *;
+
+-- name: GetOAuthConsent :one
+SELECT * from oauth_consents where user_id = $1 and client_id = $2 LIMIT 1;
+
+-- name: GetOAuthConsentsForUser :many
+SELECT oauth_consents.client_id, oauth_clients.name, oauth_consents.scopes, oauth_consents.created_at, oauth_consents.updated_at from oauth_consents join oauth_clients on oauth_consents.client_id = oauth_clients.id where oauth_consents.user_id = $1 order by oauth_consents.updated_at desc;
+
+-- name: DeleteOAuthConsent :one
+DELETE from oauth_consents where user_id = $1 and client_id = $2 returning *;
+
+-- name: CreateOAuthAccessToken :one
+INSERT INTO oauth_access_tokens(token_hash, client_id, user_id, scopes, expires_at) values($1, $2, $3, $4, $5) returning *;
+
+-- name: GetOAuthAccessToken :one
+SELECT * from oauth_access_tokens where token_hash = $1 LIMIT 1;
+
+-- name: RevokeOAuthAccessTokens :exec
+UPDATE oauth_access_tokens set revoked_at = Now() where user_id = $1 and client_id = $2 and revoked_at is null;
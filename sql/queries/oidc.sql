@@ -0,0 +1,11 @@
+-- name: CreateOIDCLoginState :one
+INSERT INTO oidc_login_states(state, nonce, code_verifier, link_user_id, expires_at) values($1, $2, $3, $4, $5) returning *;
+
+-- name: ConsumeOIDCLoginState :one
+DELETE from oidc_login_states where state = $1 returning *;
+
+-- name: GetUserIdentity :one
+SELECT * from user_identities where issuer = $1 and subject = $2 LIMIT 1;
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities(id, user_id, issuer, subject, email, created_at, updated_at) values(gen_random_uuid(), $1, $2, $3, $4, Now(), Now()) returning *;
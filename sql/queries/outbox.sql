@@ -0,0 +1,16 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events(id, event, user_id, payload, attempts, next_attempt_at, created_at) values(gen_random_uuid(), $1, $2, $3, 0, Now(), Now()) returning *;
+
+-- name: ClaimOutboxEvents :many
+UPDATE outbox_events set next_attempt_at = Now() + interval '1 minute'
+where id IN (select id from outbox_events where published_at IS NULL and next_attempt_at <= Now() order by created_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+returning *;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events set published_at = Now(), attempts = attempts + 1, last_error = NULL where id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_events set attempts = attempts + 1, last_error = $2, next_attempt_at = $3 where id = $1;
+
+-- name: DeletePublishedOutboxEvents :execrows
+DELETE from outbox_events where published_at < $1;
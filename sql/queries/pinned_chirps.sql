@@ -0,0 +1,11 @@
+-- name: PinChirp :execrows
+INSERT INTO pinned_chirps(chirp_id, user_id, pinned_at) values($1, $2, Now()) ON CONFLICT (chirp_id) DO NOTHING;
+
+-- name: UnpinChirp :execrows
+DELETE from pinned_chirps where chirp_id = $1 and user_id = $2;
+
+-- name: CountPinnedChirps :one
+select count(*) from pinned_chirps where user_id = $1;
+
+-- name: GetPinnedChirpIDs :many
+select chirp_id from pinned_chirps where user_id = $1 order by pinned_at desc;
@@ -0,0 +1,6 @@
+-- name: CreatePolkaEvent :execrows
+INSERT INTO polka_events(event_id, event, user_id, created_at) values($1, $2, $3, Now()) ON CONFLICT (event_id) DO NOTHING;
+
+-- name: CreatePolkaDelivery :exec
+INSERT INTO polka_deliveries(id, event_id, event, payload, status, received_at) values(gen_random_uuid(), $1, $2, $3, $4, Now());
+
@@ -0,0 +1,24 @@
+-- name: CreatePoll :exec
+INSERT INTO polls(chirp_id, closes_at, created_at) VALUES($1, $2, Now());
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options(id, chirp_id, position, text) VALUES(gen_random_uuid(), $1, $2, $3);
+
+-- name: GetPoll :one
+select * from polls where chirp_id = $1;
+
+-- name: GetPollOption :one
+select * from poll_options where id = $1;
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes(chirp_id, user_id, option_id, created_at) VALUES($1, $2, $3, Now()) ON CONFLICT (chirp_id, user_id) DO NOTHING;
+
+-- name: GetPollOptionsForChirps :many
+select o.chirp_id, p.closes_at, o.id, o.position, o.text, count(v.user_id) as votes from poll_options o
+join polls p on p.chirp_id = o.chirp_id
+left join poll_votes v on v.option_id = o.id
+where o.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+group by o.id, p.closes_at order by o.chirp_id, o.position;
+
+-- name: GetPollVotesByUser :many
+select chirp_id, option_id from poll_votes where user_id = sqlc.arg(user_id) and chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
@@ -9,3 +9,6 @@ SELECT refresh_tokens.token,users.* from refresh_tokens join users on refresh_to
 
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens SET revoked_at = $1, updated_at = $2 where token = $3;
+
+-- name: RevokeUserRefreshTokens :execrows
+UPDATE refresh_tokens SET revoked_at = Now(), updated_at = Now() where user_id = $1 and revoked_at IS NULL;
@@ -0,0 +1,14 @@
+-- name: GetSubscriptionForUser :one
+SELECT * from subscriptions where user_id = $1 LIMIT 1;
+
+-- name: UpsertSubscription :one
+INSERT INTO subscriptions(id, user_id, plan, status, started_at, expires_at, created_at, updated_at) values(gen_random_uuid(), $1, $2, 'active', Now(), $3, Now(), Now()) ON CONFLICT (user_id) DO UPDATE SET plan = excluded.plan, started_at = CASE WHEN subscriptions.status = 'active' THEN subscriptions.started_at ELSE Now() END, renewed_at = CASE WHEN subscriptions.status = 'active' THEN Now() ELSE NULL END, status = 'active', expires_at = excluded.expires_at, updated_at = Now() returning *;
+
+-- name: CancelSubscription :exec
+UPDATE subscriptions set status = 'canceled', updated_at = Now() where user_id = $1 and status = 'active';
+
+-- name: ExpireLapsedSubscriptions :execrows
+WITH expired AS (UPDATE subscriptions set status = 'expired', updated_at = Now() where status = 'active' and expires_at <= Now() returning user_id) UPDATE users set is_chirpy_red = false where id in (SELECT user_id from expired);
+
+-- name: SyncUserChirpyRed :one
+UPDATE users set is_chirpy_red = EXISTS(SELECT 1 from subscriptions where subscriptions.user_id = users.id and subscriptions.status = 'active' and subscriptions.expires_at > Now()) where id = $1 returning *;
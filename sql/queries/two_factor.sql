@@ -0,0 +1,32 @@
+-- name: UpsertUserTOTP :one
+INSERT INTO user_totp(user_id, secret, enabled, created_at, updated_at) values($1, $2, false, Now(), Now()) ON CONFLICT (user_id) DO UPDATE SET secret = excluded.secret, enabled = false, last_used_step = 0, updated_at = Now() returning *;
+
+-- name: GetUserTOTP :one
+SELECT * from user_totp where user_id = $1 LIMIT 1;
+
+-- name: EnableUserTOTP :exec
+UPDATE user_totp set enabled = true, last_used_step = $1, updated_at = Now() where user_id = $2;
+
+-- name: UpdateTOTPLastUsedStep :execrows
+UPDATE user_totp set last_used_step = $1, updated_at = Now() where user_id = $2 and last_used_step < $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes(id, user_id, code_hash, created_at) values(gen_random_uuid(), $1, $2, Now());
+
+-- name: DeleteRecoveryCodes :exec
+DELETE from recovery_codes where user_id = $1;
+
+-- name: UseRecoveryCode :one
+UPDATE recovery_codes set used_at = Now() where user_id = $1 and code_hash = $2 and used_at is null returning *;
+
+-- name: CreateTwoFactorChallenge :one
+INSERT INTO two_factor_challenges(token, user_id, expires_at) values($1, $2, $3) returning *;
+
+-- name: GetTwoFactorChallenge :one
+SELECT * from two_factor_challenges where token = $1 LIMIT 1;
+
+-- name: ClaimTwoFactorChallengeAttempt :one
+UPDATE two_factor_challenges set attempts = attempts + 1 where token = $1 and attempts < sqlc.arg(max_attempts) returning attempts;
+
+-- name: DeleteTwoFactorChallenge :exec
+DELETE from two_factor_challenges where token = $1;
@@ -12,6 +12,3 @@ UPDATE users set email = $1, hashed_password = $2 where id = $3 returning id, em
 
 -- name: DeleteAllUsers :exec
 DELETE from users;
-
--- name: UpgradeUserToRed :one
-UPDATE users set is_chirpy_red = true where id = $1 returning *;
@@ -0,0 +1,34 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions(id, user_id, url, secret, events, active, created_at, updated_at) values(gen_random_uuid(), $1, $2, $3, $4, true, Now(), Now()) returning *;
+
+-- name: GetWebhookSubscription :one
+select * from webhook_subscriptions where id = $1;
+
+-- name: GetWebhookSubscriptionsForOwner :many
+select * from webhook_subscriptions where user_id IS NOT DISTINCT FROM $1 order by created_at desc;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE from webhook_subscriptions where id = $1 and user_id IS NOT DISTINCT FROM $2;
+
+-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries(id, subscription_id, event_id, event, payload, status, attempts, next_attempt_at, created_at, updated_at)
+SELECT gen_random_uuid(), id, sqlc.arg(event_id), sqlc.arg(event)::text, sqlc.arg(payload), 'pending', 0, Now(), Now(), Now() from webhook_subscriptions
+where active = true and sqlc.arg(event)::text = ANY(events) and (user_id IS NULL or user_id = sqlc.arg(user_id))
+ON CONFLICT (subscription_id, event_id) DO NOTHING;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries set next_attempt_at = Now() + interval '5 minutes', updated_at = Now()
+where id IN (select id from webhook_deliveries where status = 'pending' and next_attempt_at <= Now() order by next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+returning *;
+
+-- name: MarkWebhookDeliveryDelivered :exec
+UPDATE webhook_deliveries set status = 'delivered', attempts = attempts + 1, last_status_code = $2, last_error = NULL, delivered_at = Now(), updated_at = Now() where id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries set status = $2, attempts = attempts + 1, last_status_code = $3, last_error = $4, next_attempt_at = $5, updated_at = Now() where id = $1;
+
+-- name: GetWebhookDeliveries :many
+select * from webhook_deliveries where subscription_id = $1 order by created_at desc LIMIT $2;
+
+-- name: RequeueWebhookDelivery :execrows
+UPDATE webhook_deliveries set status = 'pending', attempts = 0, next_attempt_at = Now(), updated_at = Now() where id = $1 and subscription_id = $2 and status = 'dead';
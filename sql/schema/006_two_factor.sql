@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE user_totp(user_id UUID PRIMARY KEY NOT NULL, secret TEXT NOT NULL, enabled BOOLEAN NOT NULL DEFAULT false, last_used_step BIGINT NOT NULL DEFAULT 0, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+CREATE TABLE recovery_codes(id UUID PRIMARY KEY NOT NULL, user_id UUID NOT NULL, code_hash TEXT NOT NULL, used_at TIMESTAMP, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+CREATE TABLE two_factor_challenges(token TEXT PRIMARY KEY NOT NULL, user_id UUID NOT NULL, attempts INTEGER NOT NULL DEFAULT 0, expires_at TIMESTAMP NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+
+-- +goose Down
+DROP TABLE two_factor_challenges;
+DROP TABLE recovery_codes;
+DROP TABLE user_totp;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE TABLE api_keys(id UUID PRIMARY KEY NOT NULL, user_id UUID NOT NULL, name TEXT NOT NULL, key_hash TEXT NOT NULL UNIQUE, prefix TEXT NOT NULL, scopes TEXT[] NOT NULL, expires_at TIMESTAMP, last_used_at TIMESTAMP, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+
+-- +goose Down
+DROP TABLE api_keys;
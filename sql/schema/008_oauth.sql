@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE oauth_clients(id UUID PRIMARY KEY NOT NULL, owner_id UUID NOT NULL, name TEXT NOT NULL, secret_hash TEXT, redirect_uris TEXT[] NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_owner_id FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE cascade);
+CREATE TABLE oauth_authorization_codes(code_hash TEXT PRIMARY KEY NOT NULL, client_id UUID NOT NULL, user_id UUID NOT NULL, redirect_uri TEXT NOT NULL, scopes TEXT[] NOT NULL, code_challenge TEXT NOT NULL, expires_at TIMESTAMP NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_client_id FOREIGN KEY (client_id) REFERENCES oauth_clients(id) ON DELETE cascade, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+CREATE TABLE oauth_consents(user_id UUID NOT NULL, client_id UUID NOT NULL, scopes TEXT[] NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (user_id, client_id), CONSTRAINT fk_client_id FOREIGN KEY (client_id) REFERENCES oauth_clients(id) ON DELETE cascade, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+CREATE TABLE oauth_access_tokens(token_hash TEXT PRIMARY KEY NOT NULL, client_id UUID NOT NULL, user_id UUID NOT NULL, scopes TEXT[] NOT NULL, expires_at TIMESTAMP NOT NULL, revoked_at TIMESTAMP, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_client_id FOREIGN KEY (client_id) REFERENCES oauth_clients(id) ON DELETE cascade, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+
+-- +goose Down
+DROP TABLE oauth_access_tokens;
+DROP TABLE oauth_consents;
+DROP TABLE oauth_authorization_codes;
+DROP TABLE oauth_clients;
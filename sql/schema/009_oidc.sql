@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE TABLE user_identities(id UUID PRIMARY KEY NOT NULL, user_id UUID NOT NULL, issuer TEXT NOT NULL, subject TEXT NOT NULL, email TEXT NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, UNIQUE (issuer, subject), CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+CREATE TABLE oidc_login_states(state TEXT PRIMARY KEY NOT NULL, nonce TEXT NOT NULL, code_verifier TEXT NOT NULL, link_user_id UUID, expires_at TIMESTAMP NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_link_user_id FOREIGN KEY (link_user_id) REFERENCES users(id) ON DELETE cascade);
+
+-- +goose Down
+DROP TABLE oidc_login_states;
+DROP TABLE user_identities;
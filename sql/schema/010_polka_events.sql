@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE TABLE polka_events(event_id TEXT PRIMARY KEY NOT NULL, event TEXT NOT NULL, user_id UUID NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP);
+CREATE TABLE polka_deliveries(id UUID PRIMARY KEY NOT NULL, event_id TEXT NOT NULL, event TEXT NOT NULL, payload JSONB NOT NULL, status TEXT NOT NULL, received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP);
+
+-- +goose Down
+DROP TABLE polka_deliveries;
+DROP TABLE polka_events;
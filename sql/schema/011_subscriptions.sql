@@ -0,0 +1,6 @@
+-- +goose Up
+CREATE TABLE subscriptions(id UUID PRIMARY KEY NOT NULL, user_id UUID NOT NULL UNIQUE, plan TEXT NOT NULL, status TEXT NOT NULL, started_at TIMESTAMP NOT NULL, renewed_at TIMESTAMP, expires_at TIMESTAMP NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+INSERT INTO subscriptions(id, user_id, plan, status, started_at, expires_at) SELECT gen_random_uuid(), id, 'monthly', 'active', Now(), Now() + interval '1 month' from users where is_chirpy_red = true;
+
+-- +goose Down
+DROP TABLE subscriptions;
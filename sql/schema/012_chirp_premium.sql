@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN published BOOLEAN NOT NULL DEFAULT true, ADD COLUMN publish_at TIMESTAMP, ADD COLUMN edited_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN edited_at, DROP COLUMN publish_at, DROP COLUMN published;
@@ -0,0 +1,8 @@
+-- +goose Up
+CREATE TABLE webhook_subscriptions(id UUID PRIMARY KEY NOT NULL, user_id UUID, url TEXT NOT NULL, secret TEXT NOT NULL, events TEXT[] NOT NULL, active BOOLEAN NOT NULL DEFAULT true, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+CREATE TABLE webhook_deliveries(id UUID PRIMARY KEY NOT NULL, subscription_id UUID NOT NULL, event TEXT NOT NULL, payload JSONB NOT NULL, status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'dead')), attempts INTEGER NOT NULL DEFAULT 0, next_attempt_at TIMESTAMP NOT NULL, last_status_code INTEGER, last_error TEXT, delivered_at TIMESTAMP, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_subscription_id FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE cascade);
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_subscriptions;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE outbox_events(id UUID PRIMARY KEY NOT NULL, event TEXT NOT NULL, user_id UUID, payload JSONB NOT NULL, attempts INTEGER NOT NULL DEFAULT 0, last_error TEXT, next_attempt_at TIMESTAMP NOT NULL, published_at TIMESTAMP, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP);
+CREATE INDEX outbox_events_pending_idx ON outbox_events(next_attempt_at) WHERE published_at IS NULL;
+ALTER TABLE webhook_deliveries ADD COLUMN event_id UUID;
+CREATE UNIQUE INDEX webhook_deliveries_event_idx ON webhook_deliveries(subscription_id, event_id);
+
+-- +goose Down
+DROP INDEX webhook_deliveries_event_idx;
+ALTER TABLE webhook_deliveries DROP COLUMN event_id;
+DROP TABLE outbox_events;
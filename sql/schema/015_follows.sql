@@ -0,0 +1,6 @@
+-- +goose Up
+CREATE TABLE follows(follower_id UUID NOT NULL, followee_id UUID NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (follower_id, followee_id), CONSTRAINT fk_follower_id FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE cascade, CONSTRAINT fk_followee_id FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE cascade);
+CREATE INDEX follows_followee_idx ON follows(followee_id);
+
+-- +goose Down
+DROP TABLE follows;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN reply_to_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+CREATE TABLE likes(user_id UUID NOT NULL, chirp_id UUID NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (user_id, chirp_id), CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade, CONSTRAINT fk_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE cascade);
+CREATE TABLE notifications(id UUID PRIMARY KEY NOT NULL, user_id UUID NOT NULL, event_id UUID NOT NULL, type TEXT NOT NULL CHECK (type IN ('mention', 'reply', 'follow', 'like', 'chirpy_red')), actor_id UUID, chirp_id UUID, read_at TIMESTAMP, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, UNIQUE (event_id, user_id, type), CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade, CONSTRAINT fk_actor_id FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE cascade, CONSTRAINT fk_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE cascade);
+CREATE INDEX notifications_user_created_idx ON notifications(user_id, created_at DESC);
+CREATE TABLE notification_preferences(user_id UUID PRIMARY KEY NOT NULL, mentions BOOLEAN NOT NULL DEFAULT true, replies BOOLEAN NOT NULL DEFAULT true, follows BOOLEAN NOT NULL DEFAULT true, likes BOOLEAN NOT NULL DEFAULT true, chirpy_red BOOLEAN NOT NULL DEFAULT true, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+
+-- +goose Down
+DROP TABLE notification_preferences;
+DROP TABLE notifications;
+DROP TABLE likes;
+ALTER TABLE chirps DROP COLUMN reply_to_id;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE conversations(id UUID PRIMARY KEY NOT NULL, created_by UUID NOT NULL, is_group BOOLEAN NOT NULL, title TEXT, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, last_message_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE cascade);
+CREATE TABLE conversation_members(conversation_id UUID NOT NULL, user_id UUID NOT NULL, joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, last_read_at TIMESTAMP, PRIMARY KEY (conversation_id, user_id), CONSTRAINT fk_conversation_id FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE cascade, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+CREATE INDEX conversation_members_user_idx ON conversation_members(user_id);
+CREATE TABLE messages(id UUID PRIMARY KEY NOT NULL, conversation_id UUID NOT NULL, sender_id UUID NOT NULL, body TEXT NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_conversation_id FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE cascade, CONSTRAINT fk_sender_id FOREIGN KEY (sender_id) REFERENCES users(id) ON DELETE cascade);
+CREATE INDEX messages_conversation_created_idx ON messages(conversation_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE messages;
+DROP TABLE conversation_members;
+DROP TABLE conversations;
@@ -0,0 +1,8 @@
+-- +goose Up
+CREATE TABLE blocks(blocker_id UUID NOT NULL, blocked_id UUID NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (blocker_id, blocked_id), CONSTRAINT fk_blocker_id FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE cascade, CONSTRAINT fk_blocked_id FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE cascade);
+CREATE INDEX blocks_blocked_idx ON blocks(blocked_id);
+CREATE TABLE mutes(muter_id UUID NOT NULL, muted_id UUID NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (muter_id, muted_id), CONSTRAINT fk_muter_id FOREIGN KEY (muter_id) REFERENCES users(id) ON DELETE cascade, CONSTRAINT fk_muted_id FOREIGN KEY (muted_id) REFERENCES users(id) ON DELETE cascade);
+
+-- +goose Down
+DROP TABLE mutes;
+DROP TABLE blocks;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE moderators(user_id UUID PRIMARY KEY NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+ALTER TABLE chirps ADD COLUMN hidden_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN suspended_until TIMESTAMP;
+ALTER TABLE users ADD COLUMN suspension_reason TEXT;
+CREATE TABLE reports(id UUID PRIMARY KEY NOT NULL, chirp_id UUID NOT NULL, reporter_id UUID NOT NULL, reason TEXT NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'misinformation', 'other')), details TEXT, status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'resolved')), created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, resolved_at TIMESTAMP, UNIQUE (chirp_id, reporter_id), CONSTRAINT fk_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE cascade, CONSTRAINT fk_reporter_id FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE cascade);
+CREATE INDEX reports_pending_idx ON reports(chirp_id) WHERE status = 'pending';
+CREATE TABLE moderation_actions(id UUID PRIMARY KEY NOT NULL, moderator_id UUID, action TEXT NOT NULL CHECK (action IN ('dismiss', 'hide', 'delete', 'suspend')), chirp_id UUID, target_user_id UUID, note TEXT, reports_resolved INTEGER NOT NULL DEFAULT 0, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_moderator_id FOREIGN KEY (moderator_id) REFERENCES users(id) ON DELETE SET NULL, CONSTRAINT fk_target_user_id FOREIGN KEY (target_user_id) REFERENCES users(id) ON DELETE SET NULL);
+CREATE INDEX moderation_actions_created_idx ON moderation_actions(created_at DESC);
+
+-- +goose Down
+DROP TABLE moderation_actions;
+DROP TABLE reports;
+ALTER TABLE users DROP COLUMN suspension_reason;
+ALTER TABLE users DROP COLUMN suspended_until;
+ALTER TABLE users DROP COLUMN suspended_at;
+ALTER TABLE chirps DROP COLUMN hidden_at;
+DROP TABLE moderators;
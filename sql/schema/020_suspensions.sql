@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE moderation_actions DROP CONSTRAINT moderation_actions_action_check;
+ALTER TABLE moderation_actions ADD CONSTRAINT moderation_actions_action_check CHECK (action IN ('dismiss', 'hide', 'delete', 'suspend', 'unsuspend'));
+
+-- +goose Down
+DELETE FROM moderation_actions WHERE action = 'unsuspend';
+ALTER TABLE moderation_actions DROP CONSTRAINT moderation_actions_action_check;
+ALTER TABLE moderation_actions ADD CONSTRAINT moderation_actions_action_check CHECK (action IN ('dismiss', 'hide', 'delete', 'suspend'));
@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE TABLE attachments(id UUID PRIMARY KEY NOT NULL, user_id UUID NOT NULL, chirp_id UUID, position INTEGER NOT NULL DEFAULT 0, content_type TEXT NOT NULL, size_bytes BIGINT NOT NULL, width INTEGER NOT NULL, height INTEGER NOT NULL, blob_key TEXT NOT NULL, thumbnail_key TEXT NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, attached_at TIMESTAMP, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade, CONSTRAINT fk_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE SET NULL);
+CREATE INDEX attachments_chirp_idx ON attachments(chirp_id, position);
+CREATE INDEX attachments_detached_idx ON attachments(created_at) WHERE chirp_id IS NULL;
+
+-- +goose Down
+DROP TABLE attachments;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN link_url TEXT;
+CREATE TABLE link_previews(url TEXT PRIMARY KEY NOT NULL, status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')), title TEXT, description TEXT, image_url TEXT, site_name TEXT, attempts INTEGER NOT NULL DEFAULT 0, last_error TEXT, next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, fetched_at TIMESTAMP, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP);
+CREATE INDEX link_previews_due_idx ON link_previews(next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE link_previews;
+ALTER TABLE chirps DROP COLUMN link_url;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE polls(chirp_id UUID PRIMARY KEY NOT NULL, closes_at TIMESTAMP NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE cascade);
+CREATE TABLE poll_options(id UUID PRIMARY KEY NOT NULL, chirp_id UUID NOT NULL, position INTEGER NOT NULL, text TEXT NOT NULL, UNIQUE (chirp_id, position), CONSTRAINT fk_chirp_id FOREIGN KEY (chirp_id) REFERENCES polls(chirp_id) ON DELETE cascade);
+CREATE TABLE poll_votes(chirp_id UUID NOT NULL, user_id UUID NOT NULL, option_id UUID NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (chirp_id, user_id), CONSTRAINT fk_chirp_id FOREIGN KEY (chirp_id) REFERENCES polls(chirp_id) ON DELETE cascade, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade, CONSTRAINT fk_option_id FOREIGN KEY (option_id) REFERENCES poll_options(id) ON DELETE cascade);
+CREATE INDEX poll_votes_option_idx ON poll_votes(option_id);
+
+-- +goose Down
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
@@ -0,0 +1,6 @@
+-- +goose Up
+CREATE TABLE drafts(id UUID PRIMARY KEY NOT NULL, user_id UUID NOT NULL, body TEXT NOT NULL DEFAULT '', reply_to_id UUID, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+CREATE INDEX drafts_user_updated_idx ON drafts(user_id, updated_at DESC);
+
+-- +goose Down
+DROP TABLE drafts;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE bookmark_collections(id UUID PRIMARY KEY NOT NULL, user_id UUID NOT NULL, name TEXT NOT NULL, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+CREATE UNIQUE INDEX bookmark_collections_name_idx ON bookmark_collections(user_id, lower(name));
+CREATE TABLE bookmarks(user_id UUID NOT NULL, chirp_id UUID NOT NULL, collection_id UUID, created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (user_id, chirp_id), CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade, CONSTRAINT fk_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE cascade, CONSTRAINT fk_collection_id FOREIGN KEY (collection_id) REFERENCES bookmark_collections(id) ON DELETE SET NULL);
+CREATE INDEX bookmarks_user_created_idx ON bookmarks(user_id, created_at DESC);
+CREATE INDEX bookmarks_collection_idx ON bookmarks(collection_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE bookmarks;
+DROP TABLE bookmark_collections;
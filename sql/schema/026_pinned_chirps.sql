@@ -0,0 +1,6 @@
+-- +goose Up
+CREATE TABLE pinned_chirps(chirp_id UUID PRIMARY KEY NOT NULL, user_id UUID NOT NULL, pinned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE cascade, CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE cascade);
+CREATE INDEX pinned_chirps_user_idx ON pinned_chirps(user_id, pinned_at DESC);
+
+-- +goose Down
+DROP TABLE pinned_chirps;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE chirp_rankings(rank INTEGER PRIMARY KEY NOT NULL, chirp_id UUID NOT NULL, score DOUBLE PRECISION NOT NULL, ranked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, CONSTRAINT fk_chirp_id FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE cascade);
+CREATE INDEX likes_chirp_idx ON likes(chirp_id);
+CREATE INDEX chirps_reply_to_idx ON chirps(reply_to_id);
+
+-- +goose Down
+DROP INDEX chirps_reply_to_idx;
+DROP INDEX likes_chirp_idx;
+DROP TABLE chirp_rankings;
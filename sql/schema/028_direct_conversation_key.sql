@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE conversations ADD COLUMN direct_key TEXT;
+UPDATE conversations c SET direct_key = pairs.direct_key FROM (
+    SELECT DISTINCT ON (members.direct_key) members.conversation_id, members.direct_key FROM (
+        SELECT m.conversation_id, c2.created_at, string_agg(m.user_id::text, ':' ORDER BY m.user_id::text) AS direct_key
+        FROM conversation_members m JOIN conversations c2 ON c2.id = m.conversation_id
+        WHERE c2.is_group = false GROUP BY m.conversation_id, c2.created_at
+    ) members ORDER BY members.direct_key, members.created_at
+) pairs WHERE c.id = pairs.conversation_id;
+CREATE UNIQUE INDEX conversations_direct_key_idx ON conversations(direct_key);
+
+-- +goose Down
+DROP INDEX conversations_direct_key_idx;
+ALTER TABLE conversations DROP COLUMN direct_key;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- Timestamps so far were stored without a time zone, so Now() followed the
+-- session time zone while times sent from Go kept their own, and the two
+-- didn't compare. They were written as UTC, which is how they are read here.
+-- As timestamptz every value is an instant, whatever either clock's zone is.
+-- +goose StatementBegin
+DO $$
+DECLARE col record;
+BEGIN
+    FOR col IN SELECT c.table_name, c.column_name FROM information_schema.columns c
+        JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+        WHERE c.table_schema = 'public' AND t.table_type = 'BASE TABLE' AND t.table_name <> 'goose_db_version'
+        AND c.data_type = 'timestamp without time zone'
+    LOOP
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN %I TYPE TIMESTAMPTZ USING %I AT TIME ZONE ''UTC''', col.table_name, col.column_name, col.column_name);
+    END LOOP;
+END $$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DO $$
+DECLARE col record;
+BEGIN
+    FOR col IN SELECT c.table_name, c.column_name FROM information_schema.columns c
+        JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+        WHERE c.table_schema = 'public' AND t.table_type = 'BASE TABLE' AND t.table_name <> 'goose_db_version'
+        AND c.data_type = 'timestamp with time zone'
+    LOOP
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN %I TYPE TIMESTAMP USING %I AT TIME ZONE ''UTC''', col.table_name, col.column_name, col.column_name);
+    END LOOP;
+END $$;
+-- +goose StatementEnd
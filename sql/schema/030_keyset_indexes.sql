@@ -0,0 +1,27 @@
+-- +goose Up
+DROP INDEX notifications_user_created_idx;
+CREATE INDEX notifications_user_created_idx ON notifications(user_id, created_at DESC, id DESC);
+DROP INDEX messages_conversation_created_idx;
+CREATE INDEX messages_conversation_created_idx ON messages(conversation_id, created_at DESC, id DESC);
+DROP INDEX moderation_actions_created_idx;
+CREATE INDEX moderation_actions_created_idx ON moderation_actions(created_at DESC, id DESC);
+DROP INDEX drafts_user_updated_idx;
+CREATE INDEX drafts_user_updated_idx ON drafts(user_id, updated_at DESC, id DESC);
+DROP INDEX bookmarks_user_created_idx;
+CREATE INDEX bookmarks_user_created_idx ON bookmarks(user_id, created_at DESC, chirp_id DESC);
+DROP INDEX bookmarks_collection_idx;
+CREATE INDEX bookmarks_collection_idx ON bookmarks(collection_id, created_at DESC, chirp_id DESC);
+
+-- +goose Down
+DROP INDEX notifications_user_created_idx;
+CREATE INDEX notifications_user_created_idx ON notifications(user_id, created_at DESC);
+DROP INDEX messages_conversation_created_idx;
+CREATE INDEX messages_conversation_created_idx ON messages(conversation_id, created_at DESC);
+DROP INDEX moderation_actions_created_idx;
+CREATE INDEX moderation_actions_created_idx ON moderation_actions(created_at DESC);
+DROP INDEX drafts_user_updated_idx;
+CREATE INDEX drafts_user_updated_idx ON drafts(user_id, updated_at DESC);
+DROP INDEX bookmarks_user_created_idx;
+CREATE INDEX bookmarks_user_created_idx ON bookmarks(user_id, created_at DESC);
+DROP INDEX bookmarks_collection_idx;
+CREATE INDEX bookmarks_collection_idx ON bookmarks(collection_id, created_at DESC);